@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+)
+
+// simSidecar is one simulated sidecar: a client, its stream and one
+// connection id that the worker echoes to.
+type simSidecar struct {
+	client bridge.Client
+	connID string
+	seq    atomic.Uint64
+}
+
+func runLoad(ctx context.Context, cfg benchConfig) error {
+	switch {
+	case cfg.sidecars <= 0:
+		return errors.New("-sidecars must be positive")
+	case cfg.publishers <= 0:
+		return errors.New("-publishers must be positive")
+	case cfg.rate < 0:
+		return errors.New("-rate must not be negative")
+	case cfg.broadcastEvery > 0 && cfg.addr != "":
+		fmt.Fprintln(os.Stderr, "bridgebench: -broadcast-every needs a worker that handles "+broadcastAction)
+	}
+	addr, insecure := cfg.addr, cfg.insecure
+	if addr == "" {
+		srv, err := startEchoWorker(ctx, cfg.listen, cfg.compression)
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+		addr, insecure = cfg.listen, true
+	}
+
+	st := &stats{}
+	sidecars := make([]*simSidecar, 0, cfg.sidecars)
+	defer func() {
+		for _, sc := range sidecars {
+			_ = sc.client.Close()
+		}
+	}()
+	for i := range cfg.sidecars {
+		sc, err := startSidecar(ctx, cfg, addr, insecure, i, st)
+		if err != nil {
+			return fmt.Errorf("sidecar %d: %w", i, err)
+		}
+		sidecars = append(sidecars, sc)
+	}
+	for _, sc := range sidecars {
+		if err := waitConnected(ctx, sc.client, sc.connID); err != nil {
+			return err
+		}
+	}
+
+	text := payloadText(cfg.payload)
+	runCtx, cancel := context.WithTimeout(ctx, cfg.duration)
+	defer cancel()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, sc := range sidecars {
+		for range cfg.publishers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sc.publish(runCtx, cfg, text, st)
+			}()
+		}
+	}
+	if cfg.interval > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.interval)
+			defer ticker.Stop()
+			prev := st.snapshot()
+			for {
+				select {
+				case <-runCtx.Done():
+					return
+				case now := <-ticker.C:
+					prev = st.progress(os.Stdout, now.Sub(start), cfg.interval, prev)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	if ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+		case <-time.After(cfg.drain):
+		}
+	}
+	st.report(os.Stdout, cfg, elapsed)
+	return nil
+}
+
+func startSidecar(ctx context.Context, cfg benchConfig, addr string, insecure bool, i int, st *stats) (*simSidecar, error) {
+	client, err := bridge.NewClient(bridge.Options{
+		Address:     addr,
+		Insecure:    insecure,
+		NodeID:      fmt.Sprintf("bench-%d", i),
+		Namespace:   "bench",
+		Compression: cfg.compression,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sc := &simSidecar{client: client, connID: fmt.Sprintf("bench-%d-conn", i)}
+	if err := client.Start(ctx); err != nil {
+		return nil, err
+	}
+	// The default AckAuto path, as a sidecar would run it.
+	opts := bridge.HandleOptions{AckTimeout: -1}
+	err = errors.Join(
+		client.HandleDeliver(func(ctx context.Context, d *bridge.Delivery) error {
+			st.delivered.Add(1)
+			if at, ok := sentAt(d.Envelope); ok {
+				st.echo.add(time.Since(at))
+			}
+			return nil
+		}, opts),
+		client.HandleBroadcast(func(ctx context.Context, d *bridge.BroadcastDelivery) error {
+			st.broadcasts.Add(1)
+			if at, ok := sentAt(d.Envelope); ok {
+				st.broadcast.add(time.Since(at))
+			}
+			return nil
+		}, opts),
+	)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return sc, nil
+}
+
+// publish sends ingress until ctx is done, paced to the sidecar's share of
+// cfg.rate. A publisher that falls more than a second behind resets its
+// schedule instead of bursting.
+func (sc *simSidecar) publish(ctx context.Context, cfg benchConfig, text string, st *stats) {
+	var interval time.Duration
+	if cfg.rate > 0 {
+		interval = time.Duration(float64(time.Second) * float64(cfg.publishers) / cfg.rate)
+	}
+	next := time.Now()
+	for ctx.Err() == nil {
+		if interval > 0 {
+			next = next.Add(interval)
+			if wait := time.Until(next); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+			} else if wait < -time.Second {
+				next = time.Now()
+			}
+		}
+		n := sc.seq.Add(1)
+		action := echoAction
+		if cfg.broadcastEvery > 0 && n%uint64(cfg.broadcastEvery) == 0 {
+			action = broadcastAction
+		}
+		env := newIngress(sc.connID, fmt.Sprintf("%s-%d", sc.connID, n), action, text)
+		begin := time.Now()
+		err := sc.client.PublishIngress(ctx, *env)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			st.sendErrors.Add(1)
+			continue
+		}
+		st.publish.add(time.Since(begin))
+		st.sent.Add(1)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// subBucketBits splits every power of two into 16 buckets, so quantiles
+	// are within about 6% of the recorded value.
+	subBucketBits = 4
+	subBuckets    = 1 << subBucketBits
+	bucketCount   = (64 - subBucketBits + 1) * subBuckets
+)
+
+// latencies is a fixed-size log-linear histogram of durations, so long or
+// high-rate runs record in constant memory.
+type latencies struct {
+	count   atomic.Int64
+	max     atomic.Int64
+	buckets [bucketCount]atomic.Int64
+}
+
+func (l *latencies) add(d time.Duration) {
+	v := max(int64(d), 0)
+	l.buckets[bucketIndex(uint64(v))].Add(1)
+	l.count.Add(1)
+	for {
+		cur := l.max.Load()
+		if v <= cur || l.max.CompareAndSwap(cur, v) {
+			break
+		}
+	}
+}
+
+// bucketIndex keeps the top subBucketBits+1 significant bits of v.
+func bucketIndex(v uint64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1
+	sub := (v >> (exp - subBucketBits)) & (subBuckets - 1)
+	return (exp-subBucketBits+1)*subBuckets + int(sub)
+}
+
+// bucketValue returns the midpoint of the durations counted in bucket i.
+func bucketValue(i int) time.Duration {
+	if i < subBuckets {
+		return time.Duration(i)
+	}
+	shift := i/subBuckets - 1
+	lower := uint64(subBuckets+i%subBuckets) << shift
+	return time.Duration(lower + (uint64(1)<<shift)/2)
+}
+
+// percentiles returns the sample count and the given quantiles (0..1) plus
+// the maximum, zero when there are no samples.
+func (l *latencies) percentiles(qs ...float64) (int, []time.Duration) {
+	n := l.count.Load()
+	out := make([]time.Duration, len(qs)+1)
+	if n == 0 {
+		return 0, out
+	}
+	var counts [bucketCount]int64
+	var total int64
+	for i := range l.buckets {
+		counts[i] = l.buckets[i].Load()
+		total += counts[i]
+	}
+	maxSeen := time.Duration(l.max.Load())
+	for i, q := range qs {
+		rank := min(total-1, int64(q*float64(total)))
+		var cum int64
+		for b, c := range counts {
+			cum += c
+			if cum > rank {
+				out[i] = min(bucketValue(b), maxSeen)
+				break
+			}
+		}
+	}
+	out[len(qs)] = maxSeen
+	return int(total), out
+}
+
+// stats aggregates a load run across all sidecars.
+type stats struct {
+	sent       atomic.Int64
+	sendErrors atomic.Int64
+	delivered  atomic.Int64
+	broadcasts atomic.Int64
+
+	publish   latencies // PublishIngress call duration
+	echo      latencies // publish → echoed Deliver received
+	broadcast latencies // publish → Broadcast received, per receiving sidecar
+}
+
+type counters struct {
+	sent, sendErrors, delivered, broadcasts int64
+}
+
+func (s *stats) snapshot() counters {
+	return counters{s.sent.Load(), s.sendErrors.Load(), s.delivered.Load(), s.broadcasts.Load()}
+}
+
+// progress prints the rates since the previous snapshot.
+func (s *stats) progress(w io.Writer, elapsed, interval time.Duration, prev counters) counters {
+	cur := s.snapshot()
+	rate := func(n int64) float64 { return float64(n) / interval.Seconds() }
+	fmt.Fprintf(w, "%6s  sent %8.0f/s  delivered %8.0f/s  broadcast %8.0f/s  errors %d\n",
+		elapsed.Round(time.Second), rate(cur.sent-prev.sent), rate(cur.delivered-prev.delivered),
+		rate(cur.broadcasts-prev.broadcasts), cur.sendErrors)
+	return cur
+}
+
+func (s *stats) report(w io.Writer, cfg benchConfig, elapsed time.Duration) {
+	c := s.snapshot()
+	rate := func(n int64) float64 { return float64(n) / elapsed.Seconds() }
+	fmt.Fprintf(w, "\nsidecars=%d publishers=%d rate=%g/s payload=%dB broadcast-every=%d elapsed=%s\n",
+		cfg.sidecars, cfg.publishers, cfg.rate, cfg.payload, cfg.broadcastEvery, elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "ingress    sent %d (%.1f/s, %.2f MB/s payload), errors %d\n",
+		c.sent, rate(c.sent), rate(c.sent)*float64(cfg.payload)/1e6, c.sendErrors)
+	fmt.Fprintf(w, "deliver    received %d (%.1f/s)\n", c.delivered, rate(c.delivered))
+	fmt.Fprintf(w, "broadcast  received %d (%.1f/s)\n\n", c.broadcasts, rate(c.broadcasts))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "latency\tn\tp50\tp90\tp99\tp99.9\tmax\t")
+	for _, row := range []struct {
+		name string
+		l    *latencies
+	}{{"publish", &s.publish}, {"echo", &s.echo}, {"broadcast", &s.broadcast}} {
+		n, p := row.l.percentiles(0.5, 0.9, 0.99, 0.999)
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t\n", row.name, n, p[0], p[1], p[2], p[3], p[4])
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+const (
+	echoAction      = "bench.echo"
+	broadcastAction = "bench.broadcast"
+	// sentAtKey carries the publish time (unix nanoseconds) in message
+	// metadata; echoes and broadcasts keep it, so receivers can measure latency.
+	sentAtKey      = "bench_sent_at"
+	connectTimeout = 10 * time.Second
+)
+
+func newIngress(connID, requestID, action, text string) *envelope.TransportEnvelope {
+	return &envelope.TransportEnvelope{
+		ConnectionId: connID,
+		Message: &envelope.Message{
+			Action:    action,
+			RequestId: requestID,
+			Payload:   &envelope.Payload{Text: &envelope.TextPayload{Content: text}},
+			Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+				sentAtKey: structpb.NewStringValue(strconv.FormatInt(time.Now().UnixNano(), 10)),
+			}},
+		},
+	}
+}
+
+// sentAt returns the publish time stamped by newIngress.
+func sentAt(env *envelope.TransportEnvelope) (time.Time, bool) {
+	v := env.GetMessage().GetMetadata().GetFields()[sentAtKey].GetStringValue()
+	ns, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ns), true
+}
+
+func payloadText(size int) string {
+	return strings.Repeat("x", size)
+}
+
+// echoWorker is the embedded worker: it echoes ingress to the source
+// connection and turns broadcastAction into a broadcast to every sidecar.
+type echoWorker struct {
+	srv bridge.WorkerServer
+}
+
+func (w *echoWorker) OnRegister(ctx context.Context, s bridge.Session, meta bridge.RegisterMeta) error {
+	return nil
+}
+
+func (w *echoWorker) OnIngress(ctx context.Context, s bridge.Session, env envelope.TransportEnvelope) error {
+	msg := env.GetMessage()
+	if msg.GetAction() == broadcastAction {
+		_, err := w.srv.Broadcast(ctx, &envelope.TransportEnvelope{Message: msg}, bridge.BroadcastOptions{})
+		return err
+	}
+	if msg != nil {
+		msg.Kind = "response"
+	}
+	return s.SendDeliver(ctx, envelope.TransportEnvelope{
+		ConnectionId:        env.GetConnectionId(),
+		TargetConnectionIds: []string{env.GetConnectionId()},
+		Message:             msg,
+	})
+}
+
+func (w *echoWorker) OnAck(ctx context.Context, s bridge.Session, ack bridge.Ack) error {
+	return nil
+}
+
+func (w *echoWorker) OnHeartbeat(ctx context.Context, s bridge.Session, nonce string) error {
+	return s.SendHeartbeat(ctx, nonce)
+}
+
+func (w *echoWorker) OnClose(ctx context.Context, s bridge.Session) error {
+	return nil
+}
+
+// startEchoWorker serves the embedded worker on addr until ctx is done or
+// the returned server is closed.
+func startEchoWorker(ctx context.Context, addr, compression string) (bridge.WorkerServer, error) {
+	srv, err := bridge.NewServer(bridge.Options{Address: addr, Insecure: true, Compression: compression})
+	if err != nil {
+		return nil, err
+	}
+	w := &echoWorker{srv: srv}
+	go func() { _ = srv.Serve(ctx, w) }()
+	return srv, nil
+}
+
+// waitConnected announces connID on the client once its stream is up;
+// Start connects in the background.
+func waitConnected(ctx context.Context, client bridge.Client, connID string) error {
+	deadline := time.Now().Add(connectTimeout)
+	for {
+		err := client.PublishConnectionEvent(ctx, bridge.ConnectionEvent{Type: bridge.ConnectionOpened, ConnectionID: connID})
+		if !errors.Is(err, bridge.ErrNotStarted) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("not connected after %s: %w", connectTimeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
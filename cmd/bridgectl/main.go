@@ -0,0 +1,211 @@
+// Command bridgectl is a fake sidecar for poking bridge workers.
+//
+// It connects with the same bridge.Options a sidecar uses, registers, prints
+// every Deliver/Broadcast/Control it receives and acks, nacks or ignores
+// them. Envelopes are protojson TransportEnvelopes (one object, an array or
+// one object per line).
+//
+// Usage:
+//
+//	bridgectl [flags] listen              print frames until interrupted
+//	bridgectl [flags] send [file|-]...    send ingress envelopes (stdin by default), then print frames for -wait
+//	bridgectl [flags] run script.yaml     run a scenario script (YAML or JSON)
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/config"
+)
+
+const usage = `usage: bridgectl [flags] <command> [args]
+
+commands:
+  listen              connect and print deliveries, broadcasts and controls until interrupted
+  send [file|-]...    send ingress envelopes (protojson; stdin when no file), then print frames for -wait
+  run <script>        run a scenario script (YAML or JSON)
+
+flags:
+`
+
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	return fmt.Sprint(map[string]string(h))
+}
+
+func (h headerFlag) Set(v string) error {
+	key, value, ok := strings.Cut(v, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("header must be key=value, got %q", v)
+	}
+	h[key] = value
+	return nil
+}
+
+type cli struct {
+	configFile  string
+	address     string
+	nodeID      string
+	namespace   string
+	insecure    bool
+	tlsCert     string
+	tlsKey      string
+	compression string
+	headers     headerFlag
+	ackMode     string
+	nackReason  string
+	wait        time.Duration
+	jsonOutput  bool
+	// set holds the flags given on the command line; only those override -config.
+	set map[string]bool
+}
+
+func main() {
+	c := cli{headers: headerFlag{}, set: map[string]bool{}}
+	fs := flag.NewFlagSet("bridgectl", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&c.configFile, "config", "", "YAML/JSON file with config.BridgeClientConfig fields; flags override it")
+	fs.StringVar(&c.address, "addr", "", "worker address (host:port, unix:///path, inproc://name)")
+	fs.StringVar(&c.nodeID, "node", "", "sidecar node id (default bridgectl-<hostname>)")
+	fs.StringVar(&c.namespace, "namespace", "default", "register namespace")
+	fs.BoolVar(&c.insecure, "insecure", false, "disable TLS")
+	fs.StringVar(&c.tlsCert, "tls-cert", "", "client TLS certificate")
+	fs.StringVar(&c.tlsKey, "tls-key", "", "client TLS key")
+	fs.StringVar(&c.compression, "compression", "", "stream compression: gzip or zstd")
+	fs.Var(c.headers, "header", "stream metadata key=value (repeatable)")
+	fs.StringVar(&c.ackMode, "ack", "auto", "reply to deliveries and broadcasts: auto, nack or none")
+	fs.StringVar(&c.nackReason, "nack-reason", "rejected by bridgectl", "reason sent with -ack nack")
+	fs.DurationVar(&c.wait, "wait", 2*time.Second, "how long send keeps printing frames after the last envelope")
+	fs.BoolVar(&c.jsonOutput, "json", false, "print frames as JSON lines")
+	_ = fs.Parse(os.Args[1:])
+	fs.Visit(func(f *flag.Flag) { c.set[f.Name] = true })
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := c.run(ctx, fs.Arg(0), fs.Args()[1:]); err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "bridgectl:", err)
+		os.Exit(1)
+	}
+}
+
+func (c *cli) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "listen", "send", "run":
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	if command == "run" && len(args) != 1 {
+		return errors.New("run takes exactly one script file")
+	}
+	opts, err := c.options()
+	if err != nil {
+		return err
+	}
+	sc, err := newSidecar(ctx, opts, c.ackMode, c.nackReason, newPrinter(os.Stdout, c.jsonOutput))
+	if err != nil {
+		return err
+	}
+	defer sc.close()
+
+	switch command {
+	case "listen":
+		<-ctx.Done()
+		return ctx.Err()
+	case "send":
+		if len(args) == 0 {
+			args = []string{"-"}
+		}
+		for _, path := range args {
+			envs, err := readEnvelopes(path)
+			if err != nil {
+				return err
+			}
+			for _, env := range envs {
+				if err := sc.publishIngress(ctx, env); err != nil {
+					return fmt.Errorf("publish ingress: %w", err)
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(c.wait):
+		}
+		return nil
+	default:
+		script, err := loadScript(args[0])
+		if err != nil {
+			return err
+		}
+		return script.run(ctx, sc)
+	}
+}
+
+// options builds bridge.Options from -config and the connection flags.
+func (c *cli) options() (bridge.Options, error) {
+	var cfg config.BridgeClientConfig
+	if c.configFile != "" {
+		data, err := os.ReadFile(c.configFile)
+		if err != nil {
+			return bridge.Options{}, err
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return bridge.Options{}, fmt.Errorf("parse %s: %w", c.configFile, err)
+		}
+	}
+	opts := bridge.OptionsFromClientConfig(cfg)
+	if c.set["addr"] {
+		opts.Address = c.address
+	}
+	if c.set["insecure"] {
+		opts.Insecure = c.insecure
+	}
+	if c.set["compression"] {
+		opts.Compression = c.compression
+	}
+	for k, v := range c.headers {
+		if opts.Metadata == nil {
+			opts.Metadata = map[string]string{}
+		}
+		opts.Metadata[k] = v
+	}
+	if c.set["tls-cert"] {
+		opts.TLSCertFile = c.tlsCert
+	}
+	if c.set["tls-key"] {
+		opts.TLSKeyFile = c.tlsKey
+	}
+	// Flag defaults still fill in what the config leaves empty.
+	if c.set["namespace"] || opts.Namespace == "" {
+		opts.Namespace = c.namespace
+	}
+	if c.set["node"] || opts.NodeID == "" {
+		opts.NodeID = c.nodeID
+	}
+	if opts.NodeID == "" {
+		host, _ := os.Hostname()
+		opts.NodeID = "bridgectl-" + host
+	}
+	if opts.Address == "" {
+		return opts, errors.New("worker address is required (-addr or address in -config)")
+	}
+	return opts, nil
+}
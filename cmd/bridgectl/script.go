@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+)
+
+const defaultExpectTimeout = 5 * time.Second
+
+// script is a scenario run by `bridgectl run`. Each step does one thing:
+//
+//	steps:
+//	  - ingress: {message: {action: chat.send, payload: {text: {content: hi}}}}
+//	  - ingress_file: msg.json          # relative to the script
+//	  - expect: {type: deliver, action: chat.send, timeout: 2s}
+//	  - connection_event: {type: opened, connection_id: c1, user_id: 42}
+//	  - ack: nack                       # auto, nack or none
+//	    nack_reason: busy
+//	  - sleep: 500ms
+type script struct {
+	Steps []step `yaml:"steps"`
+
+	dir string
+}
+
+type step struct {
+	Ingress         map[string]any `yaml:"ingress"`
+	IngressFile     string         `yaml:"ingress_file"`
+	Expect          *expectStep    `yaml:"expect"`
+	ConnectionEvent *eventStep     `yaml:"connection_event"`
+	Ack             string         `yaml:"ack"`
+	NackReason      string         `yaml:"nack_reason"`
+	Sleep           string         `yaml:"sleep"`
+}
+
+type expectStep struct {
+	// Type is deliver, broadcast or control; empty matches any.
+	Type string `yaml:"type"`
+	// Action matches the envelope's message action; empty matches any.
+	Action  string `yaml:"action"`
+	Timeout string `yaml:"timeout"`
+}
+
+type eventStep struct {
+	Type         string            `yaml:"type"` // opened, closed or auth_changed
+	ConnectionID string            `yaml:"connection_id"`
+	UserID       int64             `yaml:"user_id"`
+	Reason       string            `yaml:"reason"`
+	Attributes   map[string]string `yaml:"attributes"`
+}
+
+var eventTypes = map[string]bridge.ConnectionEventType{
+	"opened":       bridge.ConnectionOpened,
+	"closed":       bridge.ConnectionClosed,
+	"auth_changed": bridge.ConnectionAuthChanged,
+}
+
+func loadScript(path string) (*script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &script{dir: filepath.Dir(path)}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(s.Steps) == 0 {
+		return nil, fmt.Errorf("%s: no steps", path)
+	}
+	return s, nil
+}
+
+func (s *script) run(ctx context.Context, sc *sidecar) error {
+	for i, st := range s.Steps {
+		if err := s.runStep(ctx, sc, st); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *script) runStep(ctx context.Context, sc *sidecar, st step) error {
+	switch {
+	case st.Ingress != nil:
+		raw, err := json.Marshal(st.Ingress)
+		if err != nil {
+			return err
+		}
+		env, err := decodeEnvelope(raw)
+		if err != nil {
+			return fmt.Errorf("ingress: %w", err)
+		}
+		return sc.publishIngress(ctx, env)
+	case st.IngressFile != "":
+		path := st.IngressFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(s.dir, path)
+		}
+		envs, err := readEnvelopes(path)
+		if err != nil {
+			return err
+		}
+		for _, env := range envs {
+			if err := sc.publishIngress(ctx, env); err != nil {
+				return err
+			}
+		}
+		return nil
+	case st.Expect != nil:
+		return expect(ctx, sc, *st.Expect)
+	case st.ConnectionEvent != nil:
+		ev := st.ConnectionEvent
+		typ, ok := eventTypes[ev.Type]
+		if !ok {
+			return fmt.Errorf("unknown connection event type %q", ev.Type)
+		}
+		return sc.publishEvent(ctx, bridge.ConnectionEvent{
+			Type: typ, ConnectionID: ev.ConnectionID, UserID: ev.UserID, Reason: ev.Reason, Attributes: ev.Attributes,
+		})
+	case st.Ack != "":
+		switch st.Ack {
+		case "auto", "nack", "none":
+		default:
+			return fmt.Errorf("unknown ack mode %q", st.Ack)
+		}
+		sc.setAckMode(st.Ack, st.NackReason)
+		return nil
+	case st.Sleep != "":
+		d, err := time.ParseDuration(st.Sleep)
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+			return nil
+		}
+	}
+	return errors.New("empty step")
+}
+
+// expect waits for a received frame matching e. Frames that do not match
+// are kept for later expect steps, so the worker's send order between
+// deliveries, broadcasts and controls does not matter.
+func expect(ctx context.Context, sc *sidecar, e expectStep) error {
+	timeout := defaultExpectTimeout
+	if e.Timeout != "" {
+		d, err := time.ParseDuration(e.Timeout)
+		if err != nil {
+			return err
+		}
+		timeout = d
+	}
+	match := func(f frame) bool {
+		return (e.Type == "" || f.Type == e.Type) && (e.Action == "" || f.action() == e.Action)
+	}
+	for i, f := range sc.unmatched {
+		if match(f) {
+			sc.unmatched = append(sc.unmatched[:i], sc.unmatched[i+1:]...)
+			return nil
+		}
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			want := "frame"
+			if e.Type != "" {
+				want = e.Type + " frame"
+			}
+			if e.Action != "" {
+				want += fmt.Sprintf(" with action %q", e.Action)
+			}
+			return fmt.Errorf("no %s within %s", want, timeout)
+		case f := <-sc.frames:
+			if match(f) {
+				return nil
+			}
+			sc.unmatched = append(sc.unmatched, f)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+// connectTimeout bounds how long publishing waits for the first connection.
+const connectTimeout = 10 * time.Second
+
+// frame is a worker frame received by the fake sidecar.
+type frame struct {
+	Type     string // deliver, broadcast or control
+	ID       string // request_id, broadcast_id or control_id
+	Envelope *envelope.TransportEnvelope
+	Control  *bridge.Control
+}
+
+func (f frame) action() string {
+	return f.Envelope.GetMessage().GetAction()
+}
+
+// sidecar wraps a started bridge.Client that prints and settles every frame.
+type sidecar struct {
+	client  bridge.Client
+	printer *printer
+	frames  chan frame
+	// unmatched holds frames skipped by script expect steps.
+	unmatched []frame
+
+	mu         sync.Mutex
+	ackMode    string
+	nackReason string
+}
+
+func newSidecar(ctx context.Context, opts bridge.Options, ackMode, nackReason string, p *printer) (*sidecar, error) {
+	switch ackMode {
+	case "auto", "nack", "none":
+	default:
+		return nil, fmt.Errorf("unknown ack mode %q (auto, nack or none)", ackMode)
+	}
+	client, err := bridge.NewClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	sc := &sidecar{client: client, printer: p, frames: make(chan frame, 256), ackMode: ackMode, nackReason: nackReason}
+	if err := client.Start(ctx); err != nil {
+		return nil, err
+	}
+	manual := bridge.HandleOptions{AckMode: bridge.AckManual, AckTimeout: -1}
+	err = errors.Join(
+		client.HandleDeliver(func(ctx context.Context, d *bridge.Delivery) error {
+			sc.observe(frame{Type: "deliver", ID: d.Envelope.GetMessage().GetRequestId(), Envelope: d.Envelope})
+			return sc.settle(ctx, d.Ack, d.Nack)
+		}, manual),
+		client.HandleBroadcast(func(ctx context.Context, d *bridge.BroadcastDelivery) error {
+			sc.observe(frame{Type: "broadcast", ID: d.BroadcastID, Envelope: d.Envelope})
+			return sc.settle(ctx, d.Ack, d.Nack)
+		}, manual),
+		client.HandleControl(func(ctx context.Context, ctl bridge.Control) (int, error) {
+			sc.observe(frame{Type: "control", ID: ctl.ID, Control: &ctl})
+			return 0, nil
+		}, bridge.HandleOptions{}),
+	)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return sc, nil
+}
+
+// publishIngress sends env, waiting for the first connection if needed.
+func (sc *sidecar) publishIngress(ctx context.Context, env *envelope.TransportEnvelope) error {
+	return sc.whenStarted(ctx, func() error { return sc.client.PublishIngress(ctx, *env) })
+}
+
+// publishEvent sends a connection event, waiting for the first connection if needed.
+func (sc *sidecar) publishEvent(ctx context.Context, event bridge.ConnectionEvent) error {
+	return sc.whenStarted(ctx, func() error { return sc.client.PublishConnectionEvent(ctx, event) })
+}
+
+// whenStarted retries fn while the client has not connected yet; Start
+// connects in the background.
+func (sc *sidecar) whenStarted(ctx context.Context, fn func() error) error {
+	deadline := time.NewTimer(connectTimeout)
+	defer deadline.Stop()
+	for {
+		err := fn()
+		if !errors.Is(err, bridge.ErrNotStarted) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("not connected after %s: %w", connectTimeout, err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func (sc *sidecar) observe(f frame) {
+	sc.printer.print(f)
+	select {
+	case sc.frames <- f:
+	default:
+		// Nobody is waiting on frames (listen/send); drop the oldest.
+		select {
+		case <-sc.frames:
+		default:
+		}
+		sc.frames <- f
+	}
+}
+
+func (sc *sidecar) setAckMode(mode, reason string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.ackMode = mode
+	if reason != "" {
+		sc.nackReason = reason
+	}
+}
+
+func (sc *sidecar) settle(ctx context.Context, ack func(context.Context) error, nack func(context.Context, string) error) error {
+	sc.mu.Lock()
+	mode, reason := sc.ackMode, sc.nackReason
+	sc.mu.Unlock()
+	switch mode {
+	case "auto":
+		return ack(ctx)
+	case "nack":
+		return nack(ctx, reason)
+	}
+	return nil
+}
+
+func (sc *sidecar) close() {
+	_ = sc.client.Close()
+}
+
+// printer writes received frames as text or JSON lines.
+type printer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(w io.Writer, jsonOutput bool) *printer {
+	return &printer{w: w, json: jsonOutput}
+}
+
+func (p *printer) print(f frame) {
+	var body []byte
+	if f.Envelope != nil {
+		body, _ = protojson.Marshal(f.Envelope)
+	} else if f.Control != nil {
+		body, _ = json.Marshal(f.Control)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.json {
+		out, _ := json.Marshal(struct {
+			Type string          `json:"type"`
+			ID   string          `json:"id,omitempty"`
+			Body json.RawMessage `json:"body,omitempty"`
+		}{f.Type, f.ID, body})
+		fmt.Fprintf(p.w, "%s\n", out)
+		return
+	}
+	fmt.Fprintf(p.w, "%-9s %s %s\n", f.Type, f.ID, body)
+}
+
+// readEnvelopes reads protojson envelopes from path ("-" for stdin): a
+// single object, a JSON array, or a stream of objects.
+func readEnvelopes(path string) ([]*envelope.TransportEnvelope, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raws []json.RawMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			raws = append(raws, raw)
+		}
+	}
+	envs := make([]*envelope.TransportEnvelope, 0, len(raws))
+	for i, raw := range raws {
+		env, err := decodeEnvelope(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: envelope %d: %w", path, i, err)
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+func decodeEnvelope(raw []byte) (*envelope.TransportEnvelope, error) {
+	env := &envelope.TransportEnvelope{}
+	if err := protojson.Unmarshal(raw, env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
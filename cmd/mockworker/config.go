@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Goden-Gun/transport-lib/pkg/codes"
+	"github.com/Goden-Gun/transport-lib/pkg/config"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+const (
+	defaultListenAddr = "127.0.0.1:50051"
+	defaultHTTPAddr   = "127.0.0.1:8081"
+	defaultHistory    = 1000
+)
+
+// mockConfig is the mockworker YAML (or JSON) file:
+//
+//	bridge: {listen_addr: ":50051"}   # config.BridgeServerConfig fields
+//	http_addr: 127.0.0.1:8081
+//	echo: true                        # echo ingress without a rule (default true)
+//	history: 1000                     # received frames kept for GET /received
+//	actions:
+//	  chat.send:
+//	    delay: 300ms
+//	    replies:                      # Deliver to the source connection
+//	      - message: {kind: response, payload: {text: {content: ok}}}
+//	    broadcasts:                   # Broadcast to every sidecar
+//	      - message: {action: room.news, payload: {text: {content: hi}}}
+//	  chat.fail:
+//	    error: {code: INVALID_PAYLOAD, details: name is required}
+//	  chat.crash:
+//	    error: {close_stream: true}
+type mockConfig struct {
+	Bridge   config.BridgeServerConfig `yaml:"bridge"`
+	HTTPAddr string                    `yaml:"http_addr"`
+	Echo     *bool                     `yaml:"echo"`
+	History  int                       `yaml:"history"`
+	Actions  map[string]actionConfig   `yaml:"actions"`
+}
+
+type actionConfig struct {
+	Delay      string           `yaml:"delay"`
+	Replies    []map[string]any `yaml:"replies"`
+	Broadcasts []map[string]any `yaml:"broadcasts"`
+	Error      *errorConfig     `yaml:"error"`
+}
+
+type errorConfig struct {
+	// Code is a pkg/codes symbol such as INVALID_PAYLOAD (default INTERNAL_ERROR).
+	Code    string `yaml:"code"`
+	Details string `yaml:"details"`
+	// CloseStream closes the sidecar stream instead of replying with an error.
+	CloseStream bool `yaml:"close_stream"`
+}
+
+// rule is a compiled actionConfig.
+type rule struct {
+	delay      time.Duration
+	replies    []*envelope.TransportEnvelope
+	broadcasts []*envelope.TransportEnvelope
+	err        error
+	fatal      bool
+}
+
+func loadConfig(path string) (*mockConfig, error) {
+	cfg := &mockConfig{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	if cfg.Bridge.ListenAddr == "" {
+		cfg.Bridge.ListenAddr = defaultListenAddr
+	}
+	if cfg.HTTPAddr == "" {
+		cfg.HTTPAddr = defaultHTTPAddr
+	}
+	if cfg.History <= 0 {
+		cfg.History = defaultHistory
+	}
+	cfg.Bridge.ApplyDefaults()
+	return cfg, nil
+}
+
+func (c *mockConfig) echo() bool {
+	return c.Echo == nil || *c.Echo
+}
+
+// rules compiles the action rules, failing on the first invalid one.
+func (c *mockConfig) rules() (map[string]*rule, error) {
+	rules := make(map[string]*rule, len(c.Actions))
+	for action, ac := range c.Actions {
+		r, err := ac.compile()
+		if err != nil {
+			return nil, fmt.Errorf("action %s: %w", action, err)
+		}
+		rules[action] = r
+	}
+	return rules, nil
+}
+
+func (ac actionConfig) compile() (*rule, error) {
+	r := &rule{}
+	if ac.Delay != "" {
+		d, err := time.ParseDuration(ac.Delay)
+		if err != nil {
+			return nil, fmt.Errorf("delay: %w", err)
+		}
+		r.delay = d
+	}
+	var err error
+	if r.replies, err = decodeEnvelopes(ac.Replies); err != nil {
+		return nil, fmt.Errorf("replies: %w", err)
+	}
+	if r.broadcasts, err = decodeEnvelopes(ac.Broadcasts); err != nil {
+		return nil, fmt.Errorf("broadcasts: %w", err)
+	}
+	if e := ac.Error; e != nil {
+		code := codes.ErrInternal
+		if e.Code != "" {
+			found := false
+			for _, c := range codes.Registry {
+				if c.Symbol == e.Code {
+					code, found = c, true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unknown error code %q", e.Code)
+			}
+		}
+		r.err = code
+		if e.Details != "" {
+			r.err = codes.WithDetails(code, e.Details)
+		}
+		r.fatal = e.CloseStream
+	}
+	return r, nil
+}
+
+// decodeEnvelopes converts YAML maps to protojson TransportEnvelopes.
+func decodeEnvelopes(raws []map[string]any) ([]*envelope.TransportEnvelope, error) {
+	envs := make([]*envelope.TransportEnvelope, 0, len(raws))
+	for i, raw := range raws {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("envelope %d: %w", i, err)
+		}
+		env := &envelope.TransportEnvelope{}
+		if err := protojson.Unmarshal(data, env); err != nil {
+			return nil, fmt.Errorf("envelope %d: %w", i, err)
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+// entry is one journaled inbound frame, as served by GET /received.
+type entry struct {
+	Seq       uint64          `json:"seq"`
+	At        time.Time       `json:"at"`
+	Type      string          `json:"type"` // register, ingress, ack, connection_event, close or broadcast_result
+	SessionID string          `json:"session_id,omitempty"`
+	NodeID    string          `json:"node_id,omitempty"`
+	Namespace string          `json:"namespace,omitempty"`
+	Action    string          `json:"action,omitempty"`
+	Envelope  json.RawMessage `json:"envelope,omitempty"`
+	Ack       *ackView        `json:"ack,omitempty"`
+	Event     *eventView      `json:"event,omitempty"`
+	Broadcast *broadcastView  `json:"broadcast,omitempty"`
+}
+
+type ackView struct {
+	MessageID   string `json:"message_id,omitempty"`
+	BroadcastID string `json:"broadcast_id,omitempty"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+type eventView struct {
+	Type         string            `json:"type"`
+	ConnectionID string            `json:"connection_id"`
+	UserID       int64             `json:"user_id,omitempty"`
+	Reason       string            `json:"reason,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+type broadcastView struct {
+	BroadcastID string            `json:"broadcast_id"`
+	Sessions    int               `json:"sessions"`
+	Acked       []string          `json:"acked"`
+	Failed      map[string]string `json:"failed,omitempty"`
+	Pending     []string          `json:"pending,omitempty"`
+	TimedOut    bool              `json:"timed_out"`
+}
+
+type sessionView struct {
+	ID          string    `json:"id"`
+	NodeID      string    `json:"node_id"`
+	Namespace   string    `json:"namespace,omitempty"`
+	RemoteAddr  string    `json:"remote_addr,omitempty"`
+	ConnectedAt time.Time `json:"connected_at"`
+}
+
+func rawEnvelope(env *envelope.TransportEnvelope) json.RawMessage {
+	data, err := protojson.Marshal(env)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// journal keeps the last size entries.
+type journal struct {
+	size int
+
+	mu      sync.Mutex
+	seq     uint64
+	entries []entry
+}
+
+func newJournal(size int) *journal {
+	return &journal{size: size}
+}
+
+func (j *journal) add(e entry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.seq++
+	e.Seq = j.seq
+	e.At = time.Now()
+	if len(j.entries) == j.size {
+		copy(j.entries, j.entries[1:])
+		j.entries = j.entries[:len(j.entries)-1]
+	}
+	j.entries = append(j.entries, e)
+}
+
+// query returns entries newer than after matching the non-empty filters, oldest
+// first, keeping the newest limit when limit > 0.
+func (j *journal) query(after uint64, typ, action, nodeID string, limit int) []entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := []entry{}
+	for _, e := range j.entries {
+		if e.Seq <= after || (typ != "" && e.Type != typ) || (action != "" && e.Action != action) || (nodeID != "" && e.NodeID != nodeID) {
+			continue
+		}
+		out = append(out, e)
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
+
+func (j *journal) reset() {
+	j.mu.Lock()
+	j.entries = nil
+	j.mu.Unlock()
+}
+
+// httpHandler serves the journal:
+//
+//	GET    /received?type=ingress&action=chat.send&node_id=n1&after=42&limit=10
+//	DELETE /received
+//	GET    /sessions
+func (w *worker) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /received", func(rw http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		after, err := parseUint(q.Get("after"))
+		if err != nil {
+			http.Error(rw, "after: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit, err := parseUint(q.Get("limit"))
+		if err != nil {
+			http.Error(rw, "limit: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(rw, w.journal.query(after, q.Get("type"), q.Get("action"), q.Get("node_id"), int(limit)))
+	})
+	mux.HandleFunc("DELETE /received", func(rw http.ResponseWriter, r *http.Request) {
+		w.journal.reset()
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /sessions", func(rw http.ResponseWriter, r *http.Request) {
+		writeJSON(rw, w.sessionList())
+	})
+	return mux
+}
+
+func parseUint(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func writeJSON(rw http.ResponseWriter, v any) {
+	rw.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(rw)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
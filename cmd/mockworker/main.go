@@ -0,0 +1,94 @@
+// Command mockworker is a reference worker for developing sidecars without
+// the real chat services.
+//
+// It serves the bridge with bridge.NewServer, echoes every ingress back to
+// its connection as a Deliver and, per action, answers with canned
+// envelopes, delays, errors and broadcasts from a YAML file (see
+// mockConfig). Everything it receives is kept in memory and served over
+// HTTP:
+//
+//	mockworker -config mock.yaml
+//	curl localhost:8081/received?type=ingress
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
+)
+
+func main() {
+	var (
+		configFile string
+		addr       string
+		httpAddr   string
+	)
+	flag.StringVar(&configFile, "config", "", "YAML/JSON config file (see package doc)")
+	flag.StringVar(&addr, "addr", "", "bridge listen address, overrides bridge.listen_addr (default "+defaultListenAddr+")")
+	flag.StringVar(&httpAddr, "http", "", "HTTP address for /received and /sessions, overrides http_addr (default "+defaultHTTPAddr+")")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, configFile, addr, httpAddr); err != nil {
+		fmt.Fprintln(os.Stderr, "mockworker:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, configFile, addr, httpAddr string) error {
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	if addr != "" {
+		cfg.Bridge.ListenAddr = addr
+	}
+	if httpAddr != "" {
+		cfg.HTTPAddr = httpAddr
+	}
+	rules, err := cfg.rules()
+	if err != nil {
+		return err
+	}
+
+	w := newWorker(rules, cfg.echo(), newJournal(cfg.History))
+	opts := bridge.OptionsFromServerConfig(cfg.Bridge)
+	opts.OnBroadcastResult = w.onBroadcastResult
+	srv, err := bridge.NewServer(opts)
+	if err != nil {
+		return err
+	}
+	w.srv = srv
+
+	httpSrv := &http.Server{Addr: cfg.HTTPAddr, Handler: w.httpHandler(), ReadHeaderTimeout: 5 * time.Second}
+	httpErr := make(chan error, 1)
+	go func() {
+		if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			httpErr <- err
+			_ = srv.Close()
+		}
+		close(httpErr)
+	}()
+	defer httpSrv.Close()
+
+	logger.WithFields(logger.Fields{"addr": cfg.Bridge.ListenAddr, "http": cfg.HTTPAddr}).Info("mockworker listening")
+	if err := srv.Serve(ctx, w); err != nil {
+		return err
+	}
+	select {
+	case err := <-httpErr:
+		return err
+	default:
+		return nil
+	}
+}
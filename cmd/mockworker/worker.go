@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
+)
+
+// worker is the mock bridge.Handler: it journals every inbound frame and
+// answers ingress from the configured rules, echoing when none matches.
+type worker struct {
+	srv     bridge.WorkerServer
+	rules   map[string]*rule
+	echo    bool
+	journal *journal
+
+	mu       sync.RWMutex
+	sessions map[string]bridge.Session
+}
+
+func newWorker(rules map[string]*rule, echo bool, j *journal) *worker {
+	return &worker{rules: rules, echo: echo, journal: j, sessions: map[string]bridge.Session{}}
+}
+
+func (w *worker) OnRegister(ctx context.Context, s bridge.Session, meta bridge.RegisterMeta) error {
+	w.mu.Lock()
+	w.sessions[s.ID()] = s
+	w.mu.Unlock()
+	w.journal.add(entry{Type: "register", SessionID: s.ID(), NodeID: meta.NodeID, Namespace: meta.Namespace})
+	return nil
+}
+
+func (w *worker) OnIngress(ctx context.Context, s bridge.Session, env envelope.TransportEnvelope) error {
+	w.journal.add(entry{Type: "ingress", SessionID: s.ID(), NodeID: s.Metadata().NodeID, Action: env.GetMessage().GetAction(), Envelope: rawEnvelope(&env)})
+	r := w.rules[env.GetMessage().GetAction()]
+	if r == nil {
+		if !w.echo {
+			return nil
+		}
+		r = &rule{}
+	}
+	if r.delay <= 0 {
+		return w.respond(ctx, s, &env, r)
+	}
+	// Delayed answers must not hold up the sidecar's other frames.
+	src := proto.Clone(&env).(*envelope.TransportEnvelope)
+	go func() {
+		ctx := s.Context()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.delay):
+		}
+		if err := w.respond(ctx, s, src, r); err != nil {
+			w.fail(ctx, s, src, err)
+		}
+	}()
+	return nil
+}
+
+// respond sends r's broadcasts and replies (or an echo), then returns r's error.
+func (w *worker) respond(ctx context.Context, s bridge.Session, src *envelope.TransportEnvelope, r *rule) error {
+	for _, tmpl := range r.broadcasts {
+		env := proto.Clone(tmpl).(*envelope.TransportEnvelope)
+		if _, err := w.srv.Broadcast(ctx, env, bridge.BroadcastOptions{}); err != nil {
+			return err
+		}
+	}
+	replies := r.replies
+	if len(replies) == 0 && r.err == nil && len(r.broadcasts) == 0 {
+		replies = []*envelope.TransportEnvelope{{Message: proto.Clone(src.GetMessage()).(*envelope.Message)}}
+	}
+	for _, tmpl := range replies {
+		if err := s.SendDeliver(ctx, *reply(src, tmpl)); err != nil {
+			return err
+		}
+	}
+	if r.err != nil && r.fatal {
+		return bridge.Fatal(r.err)
+	}
+	return r.err
+}
+
+// fail applies the bridge error policy to errors of delayed answers.
+func (w *worker) fail(ctx context.Context, s bridge.Session, src *envelope.TransportEnvelope, err error) {
+	if bridge.IsFatal(err) {
+		_ = s.Close()
+		return
+	}
+	if err := s.SendDeliver(ctx, *bridge.ErrorReply(src, err)); err != nil {
+		logger.WithTrace(ctx).WithError(err).Warn("mockworker: send error reply")
+	}
+}
+
+// reply addresses a copy of tmpl to src's connection, filling the message
+// action, request id and kind when the template leaves them empty.
+func reply(src, tmpl *envelope.TransportEnvelope) *envelope.TransportEnvelope {
+	env := proto.Clone(tmpl).(*envelope.TransportEnvelope)
+	if env.ConnectionId == "" {
+		env.ConnectionId = src.GetConnectionId()
+	}
+	if env.UserId == 0 {
+		env.UserId = src.GetUserId()
+	}
+	if env.Namespace == "" {
+		env.Namespace = src.GetNamespace()
+	}
+	if env.TraceId == "" {
+		env.TraceId = src.GetTraceId()
+	}
+	if len(env.TargetConnectionIds) == 0 && len(env.TargetUserIds) == 0 && src.GetConnectionId() != "" {
+		env.TargetConnectionIds = []string{src.GetConnectionId()}
+	}
+	if env.Message == nil {
+		env.Message = &envelope.Message{}
+	}
+	if m := src.GetMessage(); m != nil {
+		if env.Message.Action == "" {
+			env.Message.Action = m.Action
+		}
+		if env.Message.RequestId == "" {
+			env.Message.RequestId = m.RequestId
+		}
+		if env.Message.ConversationId == "" {
+			env.Message.ConversationId = m.ConversationId
+		}
+	}
+	if env.Message.Kind == "" {
+		env.Message.Kind = "response"
+	}
+	envelope.NormalizeEnvelope(env)
+	return env
+}
+
+func (w *worker) OnAck(ctx context.Context, s bridge.Session, ack bridge.Ack) error {
+	w.journal.add(entry{Type: "ack", SessionID: s.ID(), NodeID: s.Metadata().NodeID, Ack: &ackView{
+		MessageID: ack.MessageID, BroadcastID: ack.BroadcastID, Status: ack.Status, Reason: ack.Reason,
+	}})
+	return nil
+}
+
+func (w *worker) OnHeartbeat(ctx context.Context, s bridge.Session, nonce string) error {
+	return s.SendHeartbeat(ctx, nonce)
+}
+
+func (w *worker) OnConnectionEvent(ctx context.Context, s bridge.Session, event bridge.ConnectionEvent) error {
+	w.journal.add(entry{Type: "connection_event", SessionID: s.ID(), NodeID: s.Metadata().NodeID, Event: &eventView{
+		Type: event.Type.String(), ConnectionID: event.ConnectionID, UserID: event.UserID, Reason: event.Reason, Attributes: event.Attributes,
+	}})
+	return nil
+}
+
+func (w *worker) OnClose(ctx context.Context, s bridge.Session) error {
+	w.mu.Lock()
+	delete(w.sessions, s.ID())
+	w.mu.Unlock()
+	w.journal.add(entry{Type: "close", SessionID: s.ID(), NodeID: s.Metadata().NodeID})
+	return nil
+}
+
+func (w *worker) onBroadcastResult(res bridge.BroadcastResult) {
+	w.journal.add(entry{Type: "broadcast_result", Broadcast: &broadcastView{
+		BroadcastID: res.BroadcastID, Sessions: res.Sessions, Acked: res.Acked, Failed: res.Failed, Pending: res.Pending, TimedOut: res.TimedOut,
+	}})
+}
+
+// sessionList describes the connected sidecars for GET /sessions.
+func (w *worker) sessionList() []sessionView {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	out := make([]sessionView, 0, len(w.sessions))
+	for _, s := range w.sessions {
+		v := sessionView{ID: s.ID(), NodeID: s.Metadata().NodeID, Namespace: s.Metadata().Namespace, ConnectedAt: s.ConnectedAt()}
+		if addr := s.RemoteAddr(); addr != nil {
+			v.RemoteAddr = addr.String()
+		}
+		out = append(out, v)
+	}
+	return out
+}
@@ -23,6 +23,112 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ControlCommand is a worker-to-sidecar command applied to client connections.
+type ControlCommand int32
+
+const (
+	ControlCommand_CONTROL_COMMAND_UNSPECIFIED ControlCommand = 0
+	ControlCommand_CONTROL_COMMAND_KICK        ControlCommand = 1 // Disconnect connection_ids
+	ControlCommand_CONTROL_COMMAND_CLOSE_USER  ControlCommand = 2 // Close every connection of user_ids
+	ControlCommand_CONTROL_COMMAND_THROTTLE    ControlCommand = 3 // Rate-limit connection_ids/user_ids (see params)
+)
+
+// Enum value maps for ControlCommand.
+var (
+	ControlCommand_name = map[int32]string{
+		0: "CONTROL_COMMAND_UNSPECIFIED",
+		1: "CONTROL_COMMAND_KICK",
+		2: "CONTROL_COMMAND_CLOSE_USER",
+		3: "CONTROL_COMMAND_THROTTLE",
+	}
+	ControlCommand_value = map[string]int32{
+		"CONTROL_COMMAND_UNSPECIFIED": 0,
+		"CONTROL_COMMAND_KICK":        1,
+		"CONTROL_COMMAND_CLOSE_USER":  2,
+		"CONTROL_COMMAND_THROTTLE":    3,
+	}
+)
+
+func (x ControlCommand) Enum() *ControlCommand {
+	p := new(ControlCommand)
+	*p = x
+	return p
+}
+
+func (x ControlCommand) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ControlCommand) Descriptor() protoreflect.EnumDescriptor {
+	return file_bridge_v1_bridge_proto_enumTypes[0].Descriptor()
+}
+
+func (ControlCommand) Type() protoreflect.EnumType {
+	return &file_bridge_v1_bridge_proto_enumTypes[0]
+}
+
+func (x ControlCommand) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ControlCommand.Descriptor instead.
+func (ControlCommand) EnumDescriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{0}
+}
+
+// ConnectionEventType describes a client connection lifecycle change on a sidecar.
+type ConnectionEventType int32
+
+const (
+	ConnectionEventType_CONNECTION_EVENT_TYPE_UNSPECIFIED  ConnectionEventType = 0
+	ConnectionEventType_CONNECTION_EVENT_TYPE_OPENED       ConnectionEventType = 1
+	ConnectionEventType_CONNECTION_EVENT_TYPE_CLOSED       ConnectionEventType = 2
+	ConnectionEventType_CONNECTION_EVENT_TYPE_AUTH_CHANGED ConnectionEventType = 3 // Token refreshed or user re-bound
+)
+
+// Enum value maps for ConnectionEventType.
+var (
+	ConnectionEventType_name = map[int32]string{
+		0: "CONNECTION_EVENT_TYPE_UNSPECIFIED",
+		1: "CONNECTION_EVENT_TYPE_OPENED",
+		2: "CONNECTION_EVENT_TYPE_CLOSED",
+		3: "CONNECTION_EVENT_TYPE_AUTH_CHANGED",
+	}
+	ConnectionEventType_value = map[string]int32{
+		"CONNECTION_EVENT_TYPE_UNSPECIFIED":  0,
+		"CONNECTION_EVENT_TYPE_OPENED":       1,
+		"CONNECTION_EVENT_TYPE_CLOSED":       2,
+		"CONNECTION_EVENT_TYPE_AUTH_CHANGED": 3,
+	}
+)
+
+func (x ConnectionEventType) Enum() *ConnectionEventType {
+	p := new(ConnectionEventType)
+	*p = x
+	return p
+}
+
+func (x ConnectionEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConnectionEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_bridge_v1_bridge_proto_enumTypes[1].Descriptor()
+}
+
+func (ConnectionEventType) Type() protoreflect.EnumType {
+	return &file_bridge_v1_bridge_proto_enumTypes[1]
+}
+
+func (x ConnectionEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ConnectionEventType.Descriptor instead.
+func (ConnectionEventType) EnumDescriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{1}
+}
+
 type TextPayload struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
@@ -81,6 +187,7 @@ type AudioPayload struct {
 	Seq           int64                  `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
 	Data          string                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
 	IsLast        bool                   `protobuf:"varint,4,opt,name=is_last,json=isLast,proto3" json:"is_last,omitempty"`
+	Raw           []byte                 `protobuf:"bytes,5,opt,name=raw,proto3" json:"raw,omitempty"` // Raw audio bytes; preferred over base64 data when set
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -143,17 +250,85 @@ func (x *AudioPayload) GetIsLast() bool {
 	return false
 }
 
+func (x *AudioPayload) GetRaw() []byte {
+	if x != nil {
+		return x.Raw
+	}
+	return nil
+}
+
+type BinaryPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MimeType      string                 `protobuf:"bytes,1,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"` // Optional file name
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BinaryPayload) Reset() {
+	*x = BinaryPayload{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BinaryPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BinaryPayload) ProtoMessage() {}
+
+func (x *BinaryPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BinaryPayload.ProtoReflect.Descriptor instead.
+func (*BinaryPayload) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BinaryPayload) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *BinaryPayload) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *BinaryPayload) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 type Payload struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Text          *TextPayload           `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
 	Audio         *AudioPayload          `protobuf:"bytes,2,opt,name=audio,proto3" json:"audio,omitempty"`
+	Binary        *BinaryPayload         `protobuf:"bytes,3,opt,name=binary,proto3" json:"binary,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Payload) Reset() {
 	*x = Payload{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[2]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -165,7 +340,7 @@ func (x *Payload) String() string {
 func (*Payload) ProtoMessage() {}
 
 func (x *Payload) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[2]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -178,7 +353,7 @@ func (x *Payload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Payload.ProtoReflect.Descriptor instead.
 func (*Payload) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{2}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *Payload) GetText() *TextPayload {
@@ -195,6 +370,13 @@ func (x *Payload) GetAudio() *AudioPayload {
 	return nil
 }
 
+func (x *Payload) GetBinary() *BinaryPayload {
+	if x != nil {
+		return x.Binary
+	}
+	return nil
+}
+
 type ErrorPayload struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                           // Numeric error code for programmatic handling
@@ -207,7 +389,7 @@ type ErrorPayload struct {
 
 func (x *ErrorPayload) Reset() {
 	*x = ErrorPayload{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[3]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -219,7 +401,7 @@ func (x *ErrorPayload) String() string {
 func (*ErrorPayload) ProtoMessage() {}
 
 func (x *ErrorPayload) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[3]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -232,7 +414,7 @@ func (x *ErrorPayload) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ErrorPayload.ProtoReflect.Descriptor instead.
 func (*ErrorPayload) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{3}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ErrorPayload) GetCode() int32 {
@@ -281,7 +463,7 @@ type Message struct {
 
 func (x *Message) Reset() {
 	*x = Message{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[4]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -293,7 +475,7 @@ func (x *Message) String() string {
 func (*Message) ProtoMessage() {}
 
 func (x *Message) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[4]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -306,7 +488,7 @@ func (x *Message) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Message.ProtoReflect.Descriptor instead.
 func (*Message) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{4}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Message) GetVersion() string {
@@ -394,14 +576,17 @@ type TransportEnvelope struct {
 	EnvelopeVersion     string                 `protobuf:"bytes,11,opt,name=envelope_version,json=envelopeVersion,proto3" json:"envelope_version,omitempty"`
 	SlotId              uint32                 `protobuf:"varint,12,opt,name=slot_id,json=slotId,proto3" json:"slot_id,omitempty"`
 	SlotGeneration      uint32                 `protobuf:"varint,13,opt,name=slot_generation,json=slotGeneration,proto3" json:"slot_generation,omitempty"`
-	NfcSequence         string                 `protobuf:"bytes,14,opt,name=nfc_sequence,json=nfcSequence,proto3" json:"nfc_sequence,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+	// nfc_sequence is a decimal per-connection sequence number, starting at 1
+	// and incremented by the worker for each Deliver with exactly one
+	// target_connection_ids entry; empty when unsequenced.
+	NfcSequence   string `protobuf:"bytes,14,opt,name=nfc_sequence,json=nfcSequence,proto3" json:"nfc_sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TransportEnvelope) Reset() {
 	*x = TransportEnvelope{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[5]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -413,7 +598,7 @@ func (x *TransportEnvelope) String() string {
 func (*TransportEnvelope) ProtoMessage() {}
 
 func (x *TransportEnvelope) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[5]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -426,7 +611,7 @@ func (x *TransportEnvelope) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TransportEnvelope.ProtoReflect.Descriptor instead.
 func (*TransportEnvelope) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{5}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *TransportEnvelope) GetConnectionId() string {
@@ -533,13 +718,18 @@ type RegisterFrame struct {
 	Namespace         string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
 	SupportedVersions []string               `protobuf:"bytes,3,rep,name=supported_versions,json=supportedVersions,proto3" json:"supported_versions,omitempty"`
 	BridgeVersion     string                 `protobuf:"bytes,4,opt,name=bridge_version,json=bridgeVersion,proto3" json:"bridge_version,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	// resume_token echoes the token the worker returned in the previous
+	// stream's response header; last_seq is the highest StreamResponse.seq
+	// received on it. The worker replays buffered frames after last_seq.
+	ResumeToken   string `protobuf:"bytes,5,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	LastSeq       uint64 `protobuf:"varint,6,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RegisterFrame) Reset() {
 	*x = RegisterFrame{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[6]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -551,7 +741,7 @@ func (x *RegisterFrame) String() string {
 func (*RegisterFrame) ProtoMessage() {}
 
 func (x *RegisterFrame) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[6]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -564,7 +754,7 @@ func (x *RegisterFrame) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RegisterFrame.ProtoReflect.Descriptor instead.
 func (*RegisterFrame) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{6}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *RegisterFrame) GetNodeId() string {
@@ -595,6 +785,20 @@ func (x *RegisterFrame) GetBridgeVersion() string {
 	return ""
 }
 
+func (x *RegisterFrame) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *RegisterFrame) GetLastSeq() uint64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
 type IngressFrame struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Envelope      *TransportEnvelope     `protobuf:"bytes,1,opt,name=envelope,proto3" json:"envelope,omitempty"`
@@ -604,7 +808,7 @@ type IngressFrame struct {
 
 func (x *IngressFrame) Reset() {
 	*x = IngressFrame{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[7]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -616,7 +820,7 @@ func (x *IngressFrame) String() string {
 func (*IngressFrame) ProtoMessage() {}
 
 func (x *IngressFrame) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[7]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -629,7 +833,7 @@ func (x *IngressFrame) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use IngressFrame.ProtoReflect.Descriptor instead.
 func (*IngressFrame) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{7}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *IngressFrame) GetEnvelope() *TransportEnvelope {
@@ -648,7 +852,7 @@ type DeliverFrame struct {
 
 func (x *DeliverFrame) Reset() {
 	*x = DeliverFrame{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[8]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -660,7 +864,7 @@ func (x *DeliverFrame) String() string {
 func (*DeliverFrame) ProtoMessage() {}
 
 func (x *DeliverFrame) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[8]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -673,7 +877,7 @@ func (x *DeliverFrame) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeliverFrame.ProtoReflect.Descriptor instead.
 func (*DeliverFrame) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{8}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *DeliverFrame) GetEnvelope() *TransportEnvelope {
@@ -693,7 +897,7 @@ type BroadcastFrame struct {
 
 func (x *BroadcastFrame) Reset() {
 	*x = BroadcastFrame{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[9]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -705,7 +909,7 @@ func (x *BroadcastFrame) String() string {
 func (*BroadcastFrame) ProtoMessage() {}
 
 func (x *BroadcastFrame) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[9]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -718,7 +922,7 @@ func (x *BroadcastFrame) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BroadcastFrame.ProtoReflect.Descriptor instead.
 func (*BroadcastFrame) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{9}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *BroadcastFrame) GetEnvelope() *TransportEnvelope {
@@ -739,13 +943,15 @@ type AckFrame struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
 	BroadcastId   string                 `protobuf:"bytes,2,opt,name=broadcast_id,json=broadcastId,proto3" json:"broadcast_id,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // "ok" (default when empty) or "nack"
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"` // Optional failure reason for nack
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *AckFrame) Reset() {
 	*x = AckFrame{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[10]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -757,7 +963,7 @@ func (x *AckFrame) String() string {
 func (*AckFrame) ProtoMessage() {}
 
 func (x *AckFrame) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[10]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -770,7 +976,7 @@ func (x *AckFrame) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AckFrame.ProtoReflect.Descriptor instead.
 func (*AckFrame) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{10}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *AckFrame) GetMessageId() string {
@@ -787,6 +993,20 @@ func (x *AckFrame) GetBroadcastId() string {
 	return ""
 }
 
+func (x *AckFrame) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *AckFrame) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
 type HeartbeatFrame struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Nonce         string                 `protobuf:"bytes,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
@@ -796,7 +1016,7 @@ type HeartbeatFrame struct {
 
 func (x *HeartbeatFrame) Reset() {
 	*x = HeartbeatFrame{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[11]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -808,7 +1028,7 @@ func (x *HeartbeatFrame) String() string {
 func (*HeartbeatFrame) ProtoMessage() {}
 
 func (x *HeartbeatFrame) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[11]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -821,7 +1041,7 @@ func (x *HeartbeatFrame) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HeartbeatFrame.ProtoReflect.Descriptor instead.
 func (*HeartbeatFrame) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{11}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *HeartbeatFrame) GetNonce() string {
@@ -831,34 +1051,34 @@ func (x *HeartbeatFrame) GetNonce() string {
 	return ""
 }
 
-type StreamRequest struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Types that are valid to be assigned to Payload:
-	//
-	//	*StreamRequest_Register
-	//	*StreamRequest_Ingress
-	//	*StreamRequest_Ack
-	//	*StreamRequest_Heartbeat
-	Payload       isStreamRequest_Payload `protobuf_oneof:"payload"`
+// ChunkFrame carries one part of a serialized StreamRequest/StreamResponse
+// that exceeded the sender's max frame size. Parts share chunk_id and are
+// reassembled by the receiver before dispatch.
+type ChunkFrame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChunkId       string                 `protobuf:"bytes,1,opt,name=chunk_id,json=chunkId,proto3" json:"chunk_id,omitempty"`
+	Index         uint32                 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Total         uint32                 `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	Data          []byte                 `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StreamRequest) Reset() {
-	*x = StreamRequest{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[12]
+func (x *ChunkFrame) Reset() {
+	*x = ChunkFrame{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StreamRequest) String() string {
+func (x *ChunkFrame) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StreamRequest) ProtoMessage() {}
+func (*ChunkFrame) ProtoMessage() {}
 
-func (x *StreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[12]
+func (x *ChunkFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -869,72 +1089,434 @@ func (x *StreamRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
-func (*StreamRequest) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use ChunkFrame.ProtoReflect.Descriptor instead.
+func (*ChunkFrame) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *StreamRequest) GetPayload() isStreamRequest_Payload {
+func (x *ChunkFrame) GetChunkId() string {
 	if x != nil {
-		return x.Payload
+		return x.ChunkId
 	}
-	return nil
+	return ""
 }
 
-func (x *StreamRequest) GetRegister() *RegisterFrame {
+func (x *ChunkFrame) GetIndex() uint32 {
 	if x != nil {
-		if x, ok := x.Payload.(*StreamRequest_Register); ok {
-			return x.Register
-		}
+		return x.Index
 	}
-	return nil
+	return 0
 }
 
-func (x *StreamRequest) GetIngress() *IngressFrame {
+func (x *ChunkFrame) GetTotal() uint32 {
 	if x != nil {
-		if x, ok := x.Payload.(*StreamRequest_Ingress); ok {
-			return x.Ingress
-		}
+		return x.Total
 	}
-	return nil
+	return 0
 }
 
-func (x *StreamRequest) GetAck() *AckFrame {
+func (x *ChunkFrame) GetData() []byte {
 	if x != nil {
-		if x, ok := x.Payload.(*StreamRequest_Ack); ok {
-			return x.Ack
-		}
+		return x.Data
 	}
 	return nil
 }
 
-func (x *StreamRequest) GetHeartbeat() *HeartbeatFrame {
-	if x != nil {
-		if x, ok := x.Payload.(*StreamRequest_Heartbeat); ok {
-			return x.Heartbeat
-		}
-	}
-	return nil
+// ControlFrame asks the sidecar to act on its client connections. The sidecar
+// answers with a ControlResultFrame carrying the same control_id.
+type ControlFrame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ControlId     string                 `protobuf:"bytes,1,opt,name=control_id,json=controlId,proto3" json:"control_id,omitempty"`
+	Command       ControlCommand         `protobuf:"varint,2,opt,name=command,proto3,enum=bridge.v1.ControlCommand" json:"command,omitempty"`
+	ConnectionIds []string               `protobuf:"bytes,3,rep,name=connection_ids,json=connectionIds,proto3" json:"connection_ids,omitempty"`
+	UserIds       []int64                `protobuf:"varint,4,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	Params        map[string]string      `protobuf:"bytes,6,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Command specific: throttle takes "rate_per_sec", "burst", "duration_ms"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-type isStreamRequest_Payload interface {
-	isStreamRequest_Payload()
+func (x *ControlFrame) Reset() {
+	*x = ControlFrame{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-type StreamRequest_Register struct {
-	Register *RegisterFrame `protobuf:"bytes,1,opt,name=register,proto3,oneof"`
+func (x *ControlFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-type StreamRequest_Ingress struct {
-	Ingress *IngressFrame `protobuf:"bytes,2,opt,name=ingress,proto3,oneof"`
-}
+func (*ControlFrame) ProtoMessage() {}
 
-type StreamRequest_Ack struct {
-	Ack *AckFrame `protobuf:"bytes,3,opt,name=ack,proto3,oneof"`
+func (x *ControlFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-type StreamRequest_Heartbeat struct {
-	Heartbeat *HeartbeatFrame `protobuf:"bytes,4,opt,name=heartbeat,proto3,oneof"`
+// Deprecated: Use ControlFrame.ProtoReflect.Descriptor instead.
+func (*ControlFrame) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ControlFrame) GetControlId() string {
+	if x != nil {
+		return x.ControlId
+	}
+	return ""
+}
+
+func (x *ControlFrame) GetCommand() ControlCommand {
+	if x != nil {
+		return x.Command
+	}
+	return ControlCommand_CONTROL_COMMAND_UNSPECIFIED
+}
+
+func (x *ControlFrame) GetConnectionIds() []string {
+	if x != nil {
+		return x.ConnectionIds
+	}
+	return nil
+}
+
+func (x *ControlFrame) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+func (x *ControlFrame) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ControlFrame) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+type ControlResultFrame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ControlId     string                 `protobuf:"bytes,1,opt,name=control_id,json=controlId,proto3" json:"control_id,omitempty"`
+	Ok            bool                   `protobuf:"varint,2,opt,name=ok,proto3" json:"ok,omitempty"`
+	Affected      int32                  `protobuf:"varint,3,opt,name=affected,proto3" json:"affected,omitempty"` // Number of connections the command applied to
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlResultFrame) Reset() {
+	*x = ControlResultFrame{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlResultFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlResultFrame) ProtoMessage() {}
+
+func (x *ControlResultFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlResultFrame.ProtoReflect.Descriptor instead.
+func (*ControlResultFrame) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ControlResultFrame) GetControlId() string {
+	if x != nil {
+		return x.ControlId
+	}
+	return ""
+}
+
+func (x *ControlResultFrame) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *ControlResultFrame) GetAffected() int32 {
+	if x != nil {
+		return x.Affected
+	}
+	return 0
+}
+
+func (x *ControlResultFrame) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ConnectionEventFrame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          ConnectionEventType    `protobuf:"varint,1,opt,name=type,proto3,enum=bridge.v1.ConnectionEventType" json:"type,omitempty"`
+	ConnectionId  string                 `protobuf:"bytes,2,opt,name=connection_id,json=connectionId,proto3" json:"connection_id,omitempty"`
+	UserId        int64                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"` // e.g. close reason or auth change cause
+	Attributes    map[string]string      `protobuf:"bytes,5,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectionEventFrame) Reset() {
+	*x = ConnectionEventFrame{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectionEventFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectionEventFrame) ProtoMessage() {}
+
+func (x *ConnectionEventFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectionEventFrame.ProtoReflect.Descriptor instead.
+func (*ConnectionEventFrame) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ConnectionEventFrame) GetType() ConnectionEventType {
+	if x != nil {
+		return x.Type
+	}
+	return ConnectionEventType_CONNECTION_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *ConnectionEventFrame) GetConnectionId() string {
+	if x != nil {
+		return x.ConnectionId
+	}
+	return ""
+}
+
+func (x *ConnectionEventFrame) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ConnectionEventFrame) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ConnectionEventFrame) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *ConnectionEventFrame) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+type StreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*StreamRequest_Register
+	//	*StreamRequest_Ingress
+	//	*StreamRequest_Ack
+	//	*StreamRequest_Heartbeat
+	//	*StreamRequest_Chunk
+	//	*StreamRequest_ControlResult
+	//	*StreamRequest_ConnectionEvent
+	Payload isStreamRequest_Payload `protobuf_oneof:"payload"`
+	// seq numbers Ingress frames per sidecar client (sidecar -> worker),
+	// monotonically increasing across its streams; 0 when unset. The worker
+	// drops Ingress frames it already received from the node.
+	Seq           uint64 `protobuf:"varint,15,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRequest) Reset() {
+	*x = StreamRequest{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRequest) ProtoMessage() {}
+
+func (x *StreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRequest.ProtoReflect.Descriptor instead.
+func (*StreamRequest) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *StreamRequest) GetPayload() isStreamRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetRegister() *RegisterFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*StreamRequest_Register); ok {
+			return x.Register
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetIngress() *IngressFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*StreamRequest_Ingress); ok {
+			return x.Ingress
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetAck() *AckFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*StreamRequest_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetHeartbeat() *HeartbeatFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*StreamRequest_Heartbeat); ok {
+			return x.Heartbeat
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetChunk() *ChunkFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*StreamRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetControlResult() *ControlResultFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*StreamRequest_ControlResult); ok {
+			return x.ControlResult
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetConnectionEvent() *ConnectionEventFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*StreamRequest_ConnectionEvent); ok {
+			return x.ConnectionEvent
+		}
+	}
+	return nil
+}
+
+func (x *StreamRequest) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type isStreamRequest_Payload interface {
+	isStreamRequest_Payload()
+}
+
+type StreamRequest_Register struct {
+	Register *RegisterFrame `protobuf:"bytes,1,opt,name=register,proto3,oneof"`
+}
+
+type StreamRequest_Ingress struct {
+	Ingress *IngressFrame `protobuf:"bytes,2,opt,name=ingress,proto3,oneof"`
+}
+
+type StreamRequest_Ack struct {
+	Ack *AckFrame `protobuf:"bytes,3,opt,name=ack,proto3,oneof"`
+}
+
+type StreamRequest_Heartbeat struct {
+	Heartbeat *HeartbeatFrame `protobuf:"bytes,4,opt,name=heartbeat,proto3,oneof"`
+}
+
+type StreamRequest_Chunk struct {
+	Chunk *ChunkFrame `protobuf:"bytes,5,opt,name=chunk,proto3,oneof"`
+}
+
+type StreamRequest_ControlResult struct {
+	ControlResult *ControlResultFrame `protobuf:"bytes,6,opt,name=control_result,json=controlResult,proto3,oneof"`
+}
+
+type StreamRequest_ConnectionEvent struct {
+	ConnectionEvent *ConnectionEventFrame `protobuf:"bytes,7,opt,name=connection_event,json=connectionEvent,proto3,oneof"`
 }
 
 func (*StreamRequest_Register) isStreamRequest_Payload() {}
@@ -945,6 +1527,12 @@ func (*StreamRequest_Ack) isStreamRequest_Payload() {}
 
 func (*StreamRequest_Heartbeat) isStreamRequest_Payload() {}
 
+func (*StreamRequest_Chunk) isStreamRequest_Payload() {}
+
+func (*StreamRequest_ControlResult) isStreamRequest_Payload() {}
+
+func (*StreamRequest_ConnectionEvent) isStreamRequest_Payload() {}
+
 type StreamResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Types that are valid to be assigned to Payload:
@@ -952,14 +1540,19 @@ type StreamResponse struct {
 	//	*StreamResponse_Deliver
 	//	*StreamResponse_Broadcast
 	//	*StreamResponse_Heartbeat
-	Payload       isStreamResponse_Payload `protobuf_oneof:"payload"`
+	//	*StreamResponse_Chunk
+	//	*StreamResponse_Control
+	Payload isStreamResponse_Payload `protobuf_oneof:"payload"`
+	// seq numbers Deliver/Broadcast frames per sidecar node (worker -> sidecar),
+	// monotonically increasing across that node's streams; 0 when unset.
+	Seq           uint64 `protobuf:"varint,15,opt,name=seq,proto3" json:"seq,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StreamResponse) Reset() {
 	*x = StreamResponse{}
-	mi := &file_bridge_v1_bridge_proto_msgTypes[13]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -971,7 +1564,7 @@ func (x *StreamResponse) String() string {
 func (*StreamResponse) ProtoMessage() {}
 
 func (x *StreamResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_bridge_v1_bridge_proto_msgTypes[13]
+	mi := &file_bridge_v1_bridge_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -984,7 +1577,7 @@ func (x *StreamResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StreamResponse.ProtoReflect.Descriptor instead.
 func (*StreamResponse) Descriptor() ([]byte, []int) {
-	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{13}
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *StreamResponse) GetPayload() isStreamResponse_Payload {
@@ -1021,6 +1614,31 @@ func (x *StreamResponse) GetHeartbeat() *HeartbeatFrame {
 	return nil
 }
 
+func (x *StreamResponse) GetChunk() *ChunkFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*StreamResponse_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetControl() *ControlFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*StreamResponse_Control); ok {
+			return x.Control
+		}
+	}
+	return nil
+}
+
+func (x *StreamResponse) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
 type isStreamResponse_Payload interface {
 	isStreamResponse_Payload()
 }
@@ -1037,12 +1655,141 @@ type StreamResponse_Heartbeat struct {
 	Heartbeat *HeartbeatFrame `protobuf:"bytes,3,opt,name=heartbeat,proto3,oneof"`
 }
 
+type StreamResponse_Chunk struct {
+	Chunk *ChunkFrame `protobuf:"bytes,4,opt,name=chunk,proto3,oneof"`
+}
+
+type StreamResponse_Control struct {
+	Control *ControlFrame `protobuf:"bytes,5,opt,name=control,proto3,oneof"`
+}
+
 func (*StreamResponse_Deliver) isStreamResponse_Payload() {}
 
 func (*StreamResponse_Broadcast) isStreamResponse_Payload() {}
 
 func (*StreamResponse_Heartbeat) isStreamResponse_Payload() {}
 
+func (*StreamResponse_Chunk) isStreamResponse_Payload() {}
+
+func (*StreamResponse_Control) isStreamResponse_Payload() {}
+
+// RecordedFrame is one entry of a stream recording (pkg/bridge Recorder).
+type RecordedFrame struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	RecordedAt *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=recorded_at,json=recordedAt,proto3" json:"recorded_at,omitempty"`
+	NodeId     string                 `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	// session_id is the worker session id; empty in client-side recordings.
+	SessionId string `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// side is "client" or "server", the end that recorded the frame.
+	Side string `protobuf:"bytes,4,opt,name=side,proto3" json:"side,omitempty"`
+	// Types that are valid to be assigned to Frame:
+	//
+	//	*RecordedFrame_Request
+	//	*RecordedFrame_Response
+	Frame         isRecordedFrame_Frame `protobuf_oneof:"frame"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordedFrame) Reset() {
+	*x = RecordedFrame{}
+	mi := &file_bridge_v1_bridge_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordedFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordedFrame) ProtoMessage() {}
+
+func (x *RecordedFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_bridge_v1_bridge_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordedFrame.ProtoReflect.Descriptor instead.
+func (*RecordedFrame) Descriptor() ([]byte, []int) {
+	return file_bridge_v1_bridge_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RecordedFrame) GetRecordedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RecordedAt
+	}
+	return nil
+}
+
+func (x *RecordedFrame) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *RecordedFrame) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *RecordedFrame) GetSide() string {
+	if x != nil {
+		return x.Side
+	}
+	return ""
+}
+
+func (x *RecordedFrame) GetFrame() isRecordedFrame_Frame {
+	if x != nil {
+		return x.Frame
+	}
+	return nil
+}
+
+func (x *RecordedFrame) GetRequest() *StreamRequest {
+	if x != nil {
+		if x, ok := x.Frame.(*RecordedFrame_Request); ok {
+			return x.Request
+		}
+	}
+	return nil
+}
+
+func (x *RecordedFrame) GetResponse() *StreamResponse {
+	if x != nil {
+		if x, ok := x.Frame.(*RecordedFrame_Response); ok {
+			return x.Response
+		}
+	}
+	return nil
+}
+
+type isRecordedFrame_Frame interface {
+	isRecordedFrame_Frame()
+}
+
+type RecordedFrame_Request struct {
+	Request *StreamRequest `protobuf:"bytes,5,opt,name=request,proto3,oneof"`
+}
+
+type RecordedFrame_Response struct {
+	Response *StreamResponse `protobuf:"bytes,6,opt,name=response,proto3,oneof"`
+}
+
+func (*RecordedFrame_Request) isRecordedFrame_Frame() {}
+
+func (*RecordedFrame_Response) isRecordedFrame_Frame() {}
+
 var File_bridge_v1_bridge_proto protoreflect.FileDescriptor
 
 const file_bridge_v1_bridge_proto_rawDesc = "" +
@@ -1050,15 +1797,21 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"\x16bridge/v1/bridge.proto\x12\tbridge.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"D\n" +
 	"\vTextPayload\x12\x18\n" +
 	"\acontent\x18\x01 \x01(\tR\acontent\x12\x1b\n" +
-	"\tmime_type\x18\x02 \x01(\tR\bmimeType\"c\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\"u\n" +
 	"\fAudioPayload\x12\x14\n" +
 	"\x05codec\x18\x01 \x01(\tR\x05codec\x12\x10\n" +
 	"\x03seq\x18\x02 \x01(\x03R\x03seq\x12\x12\n" +
 	"\x04data\x18\x03 \x01(\tR\x04data\x12\x17\n" +
-	"\ais_last\x18\x04 \x01(\bR\x06isLast\"d\n" +
+	"\ais_last\x18\x04 \x01(\bR\x06isLast\x12\x10\n" +
+	"\x03raw\x18\x05 \x01(\fR\x03raw\"T\n" +
+	"\rBinaryPayload\x12\x1b\n" +
+	"\tmime_type\x18\x01 \x01(\tR\bmimeType\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\x96\x01\n" +
 	"\aPayload\x12*\n" +
 	"\x04text\x18\x01 \x01(\v2\x16.bridge.v1.TextPayloadR\x04text\x12-\n" +
-	"\x05audio\x18\x02 \x01(\v2\x17.bridge.v1.AudioPayloadR\x05audio\"u\n" +
+	"\x05audio\x18\x02 \x01(\v2\x17.bridge.v1.AudioPayloadR\x05audio\x120\n" +
+	"\x06binary\x18\x03 \x01(\v2\x18.bridge.v1.BinaryPayloadR\x06binary\"u\n" +
 	"\fErrorPayload\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x1d\n" +
 	"\n" +
@@ -1099,36 +1852,103 @@ const file_bridge_v1_bridge_proto_rawDesc = "" +
 	"\fnfc_sequence\x18\x0e \x01(\tR\vnfcSequence\x1a=\n" +
 	"\x0fAttributesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9c\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xda\x01\n" +
 	"\rRegisterFrame\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x1c\n" +
 	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x12-\n" +
 	"\x12supported_versions\x18\x03 \x03(\tR\x11supportedVersions\x12%\n" +
-	"\x0ebridge_version\x18\x04 \x01(\tR\rbridgeVersion\"H\n" +
+	"\x0ebridge_version\x18\x04 \x01(\tR\rbridgeVersion\x12!\n" +
+	"\fresume_token\x18\x05 \x01(\tR\vresumeToken\x12\x19\n" +
+	"\blast_seq\x18\x06 \x01(\x04R\alastSeq\"H\n" +
 	"\fIngressFrame\x128\n" +
 	"\benvelope\x18\x01 \x01(\v2\x1c.bridge.v1.TransportEnvelopeR\benvelope\"H\n" +
 	"\fDeliverFrame\x128\n" +
 	"\benvelope\x18\x01 \x01(\v2\x1c.bridge.v1.TransportEnvelopeR\benvelope\"m\n" +
 	"\x0eBroadcastFrame\x128\n" +
 	"\benvelope\x18\x01 \x01(\v2\x1c.bridge.v1.TransportEnvelopeR\benvelope\x12!\n" +
-	"\fbroadcast_id\x18\x02 \x01(\tR\vbroadcastId\"L\n" +
+	"\fbroadcast_id\x18\x02 \x01(\tR\vbroadcastId\"|\n" +
 	"\bAckFrame\x12\x1d\n" +
 	"\n" +
 	"message_id\x18\x01 \x01(\tR\tmessageId\x12!\n" +
-	"\fbroadcast_id\x18\x02 \x01(\tR\vbroadcastId\"&\n" +
+	"\fbroadcast_id\x18\x02 \x01(\tR\vbroadcastId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"&\n" +
 	"\x0eHeartbeatFrame\x12\x14\n" +
-	"\x05nonce\x18\x01 \x01(\tR\x05nonce\"\xeb\x01\n" +
+	"\x05nonce\x18\x01 \x01(\tR\x05nonce\"g\n" +
+	"\n" +
+	"ChunkFrame\x12\x19\n" +
+	"\bchunk_id\x18\x01 \x01(\tR\achunkId\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\rR\x05index\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\rR\x05total\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\fR\x04data\"\xb4\x02\n" +
+	"\fControlFrame\x12\x1d\n" +
+	"\n" +
+	"control_id\x18\x01 \x01(\tR\tcontrolId\x123\n" +
+	"\acommand\x18\x02 \x01(\x0e2\x19.bridge.v1.ControlCommandR\acommand\x12%\n" +
+	"\x0econnection_ids\x18\x03 \x03(\tR\rconnectionIds\x12\x19\n" +
+	"\buser_ids\x18\x04 \x03(\x03R\auserIds\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x12;\n" +
+	"\x06params\x18\x06 \x03(\v2#.bridge.v1.ControlFrame.ParamsEntryR\x06params\x1a9\n" +
+	"\vParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"u\n" +
+	"\x12ControlResultFrame\x12\x1d\n" +
+	"\n" +
+	"control_id\x18\x01 \x01(\tR\tcontrolId\x12\x0e\n" +
+	"\x02ok\x18\x02 \x01(\bR\x02ok\x12\x1a\n" +
+	"\baffected\x18\x03 \x01(\x05R\baffected\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"\xed\x02\n" +
+	"\x14ConnectionEventFrame\x122\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1e.bridge.v1.ConnectionEventTypeR\x04type\x12#\n" +
+	"\rconnection_id\x18\x02 \x01(\tR\fconnectionId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12O\n" +
+	"\n" +
+	"attributes\x18\x05 \x03(\v2/.bridge.v1.ConnectionEventFrame.AttributesEntryR\n" +
+	"attributes\x12;\n" +
+	"\voccurred_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc2\x03\n" +
 	"\rStreamRequest\x126\n" +
 	"\bregister\x18\x01 \x01(\v2\x18.bridge.v1.RegisterFrameH\x00R\bregister\x123\n" +
 	"\aingress\x18\x02 \x01(\v2\x17.bridge.v1.IngressFrameH\x00R\aingress\x12'\n" +
 	"\x03ack\x18\x03 \x01(\v2\x13.bridge.v1.AckFrameH\x00R\x03ack\x129\n" +
-	"\theartbeat\x18\x04 \x01(\v2\x19.bridge.v1.HeartbeatFrameH\x00R\theartbeatB\t\n" +
-	"\apayload\"\xc6\x01\n" +
+	"\theartbeat\x18\x04 \x01(\v2\x19.bridge.v1.HeartbeatFrameH\x00R\theartbeat\x12-\n" +
+	"\x05chunk\x18\x05 \x01(\v2\x15.bridge.v1.ChunkFrameH\x00R\x05chunk\x12F\n" +
+	"\x0econtrol_result\x18\x06 \x01(\v2\x1d.bridge.v1.ControlResultFrameH\x00R\rcontrolResult\x12L\n" +
+	"\x10connection_event\x18\a \x01(\v2\x1f.bridge.v1.ConnectionEventFrameH\x00R\x0fconnectionEvent\x12\x10\n" +
+	"\x03seq\x18\x0f \x01(\x04R\x03seqB\t\n" +
+	"\apayload\"\xbc\x02\n" +
 	"\x0eStreamResponse\x123\n" +
 	"\adeliver\x18\x01 \x01(\v2\x17.bridge.v1.DeliverFrameH\x00R\adeliver\x129\n" +
 	"\tbroadcast\x18\x02 \x01(\v2\x19.bridge.v1.BroadcastFrameH\x00R\tbroadcast\x129\n" +
-	"\theartbeat\x18\x03 \x01(\v2\x19.bridge.v1.HeartbeatFrameH\x00R\theartbeatB\t\n" +
-	"\apayload2R\n" +
+	"\theartbeat\x18\x03 \x01(\v2\x19.bridge.v1.HeartbeatFrameH\x00R\theartbeat\x12-\n" +
+	"\x05chunk\x18\x04 \x01(\v2\x15.bridge.v1.ChunkFrameH\x00R\x05chunk\x123\n" +
+	"\acontrol\x18\x05 \x01(\v2\x17.bridge.v1.ControlFrameH\x00R\acontrol\x12\x10\n" +
+	"\x03seq\x18\x0f \x01(\x04R\x03seqB\t\n" +
+	"\apayload\"\x90\x02\n" +
+	"\rRecordedFrame\x12;\n" +
+	"\vrecorded_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"recordedAt\x12\x17\n" +
+	"\anode_id\x18\x02 \x01(\tR\x06nodeId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\tR\tsessionId\x12\x12\n" +
+	"\x04side\x18\x04 \x01(\tR\x04side\x124\n" +
+	"\arequest\x18\x05 \x01(\v2\x18.bridge.v1.StreamRequestH\x00R\arequest\x127\n" +
+	"\bresponse\x18\x06 \x01(\v2\x19.bridge.v1.StreamResponseH\x00R\bresponseB\a\n" +
+	"\x05frame*\x89\x01\n" +
+	"\x0eControlCommand\x12\x1f\n" +
+	"\x1bCONTROL_COMMAND_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14CONTROL_COMMAND_KICK\x10\x01\x12\x1e\n" +
+	"\x1aCONTROL_COMMAND_CLOSE_USER\x10\x02\x12\x1c\n" +
+	"\x18CONTROL_COMMAND_THROTTLE\x10\x03*\xa8\x01\n" +
+	"\x13ConnectionEventType\x12%\n" +
+	"!CONNECTION_EVENT_TYPE_UNSPECIFIED\x10\x00\x12 \n" +
+	"\x1cCONNECTION_EVENT_TYPE_OPENED\x10\x01\x12 \n" +
+	"\x1cCONNECTION_EVENT_TYPE_CLOSED\x10\x02\x12&\n" +
+	"\"CONNECTION_EVENT_TYPE_AUTH_CHANGED\x10\x032R\n" +
 	"\rSidecarBridge\x12A\n" +
 	"\x06Stream\x12\x18.bridge.v1.StreamRequest\x1a\x19.bridge.v1.StreamResponse(\x010\x01B>Z<github.com/Goden-Gun/transport-lib/gen/go/bridge/v1;bridgepbb\x06proto3"
 
@@ -1144,54 +1964,79 @@ func file_bridge_v1_bridge_proto_rawDescGZIP() []byte {
 	return file_bridge_v1_bridge_proto_rawDescData
 }
 
-var file_bridge_v1_bridge_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_bridge_v1_bridge_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_bridge_v1_bridge_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_bridge_v1_bridge_proto_goTypes = []any{
-	(*TextPayload)(nil),           // 0: bridge.v1.TextPayload
-	(*AudioPayload)(nil),          // 1: bridge.v1.AudioPayload
-	(*Payload)(nil),               // 2: bridge.v1.Payload
-	(*ErrorPayload)(nil),          // 3: bridge.v1.ErrorPayload
-	(*Message)(nil),               // 4: bridge.v1.Message
-	(*TransportEnvelope)(nil),     // 5: bridge.v1.TransportEnvelope
-	(*RegisterFrame)(nil),         // 6: bridge.v1.RegisterFrame
-	(*IngressFrame)(nil),          // 7: bridge.v1.IngressFrame
-	(*DeliverFrame)(nil),          // 8: bridge.v1.DeliverFrame
-	(*BroadcastFrame)(nil),        // 9: bridge.v1.BroadcastFrame
-	(*AckFrame)(nil),              // 10: bridge.v1.AckFrame
-	(*HeartbeatFrame)(nil),        // 11: bridge.v1.HeartbeatFrame
-	(*StreamRequest)(nil),         // 12: bridge.v1.StreamRequest
-	(*StreamResponse)(nil),        // 13: bridge.v1.StreamResponse
-	nil,                           // 14: bridge.v1.TransportEnvelope.AttributesEntry
-	(*structpb.Struct)(nil),       // 15: google.protobuf.Struct
-	(*timestamppb.Timestamp)(nil), // 16: google.protobuf.Timestamp
+	(ControlCommand)(0),           // 0: bridge.v1.ControlCommand
+	(ConnectionEventType)(0),      // 1: bridge.v1.ConnectionEventType
+	(*TextPayload)(nil),           // 2: bridge.v1.TextPayload
+	(*AudioPayload)(nil),          // 3: bridge.v1.AudioPayload
+	(*BinaryPayload)(nil),         // 4: bridge.v1.BinaryPayload
+	(*Payload)(nil),               // 5: bridge.v1.Payload
+	(*ErrorPayload)(nil),          // 6: bridge.v1.ErrorPayload
+	(*Message)(nil),               // 7: bridge.v1.Message
+	(*TransportEnvelope)(nil),     // 8: bridge.v1.TransportEnvelope
+	(*RegisterFrame)(nil),         // 9: bridge.v1.RegisterFrame
+	(*IngressFrame)(nil),          // 10: bridge.v1.IngressFrame
+	(*DeliverFrame)(nil),          // 11: bridge.v1.DeliverFrame
+	(*BroadcastFrame)(nil),        // 12: bridge.v1.BroadcastFrame
+	(*AckFrame)(nil),              // 13: bridge.v1.AckFrame
+	(*HeartbeatFrame)(nil),        // 14: bridge.v1.HeartbeatFrame
+	(*ChunkFrame)(nil),            // 15: bridge.v1.ChunkFrame
+	(*ControlFrame)(nil),          // 16: bridge.v1.ControlFrame
+	(*ControlResultFrame)(nil),    // 17: bridge.v1.ControlResultFrame
+	(*ConnectionEventFrame)(nil),  // 18: bridge.v1.ConnectionEventFrame
+	(*StreamRequest)(nil),         // 19: bridge.v1.StreamRequest
+	(*StreamResponse)(nil),        // 20: bridge.v1.StreamResponse
+	(*RecordedFrame)(nil),         // 21: bridge.v1.RecordedFrame
+	nil,                           // 22: bridge.v1.TransportEnvelope.AttributesEntry
+	nil,                           // 23: bridge.v1.ControlFrame.ParamsEntry
+	nil,                           // 24: bridge.v1.ConnectionEventFrame.AttributesEntry
+	(*structpb.Struct)(nil),       // 25: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil), // 26: google.protobuf.Timestamp
 }
 var file_bridge_v1_bridge_proto_depIdxs = []int32{
-	0,  // 0: bridge.v1.Payload.text:type_name -> bridge.v1.TextPayload
-	1,  // 1: bridge.v1.Payload.audio:type_name -> bridge.v1.AudioPayload
-	2,  // 2: bridge.v1.Message.payload:type_name -> bridge.v1.Payload
-	15, // 3: bridge.v1.Message.metadata:type_name -> google.protobuf.Struct
-	3,  // 4: bridge.v1.Message.error:type_name -> bridge.v1.ErrorPayload
-	16, // 5: bridge.v1.Message.timestamp:type_name -> google.protobuf.Timestamp
-	15, // 6: bridge.v1.Message.extras:type_name -> google.protobuf.Struct
-	4,  // 7: bridge.v1.TransportEnvelope.message:type_name -> bridge.v1.Message
-	14, // 8: bridge.v1.TransportEnvelope.attributes:type_name -> bridge.v1.TransportEnvelope.AttributesEntry
-	16, // 9: bridge.v1.TransportEnvelope.created_at:type_name -> google.protobuf.Timestamp
-	5,  // 10: bridge.v1.IngressFrame.envelope:type_name -> bridge.v1.TransportEnvelope
-	5,  // 11: bridge.v1.DeliverFrame.envelope:type_name -> bridge.v1.TransportEnvelope
-	5,  // 12: bridge.v1.BroadcastFrame.envelope:type_name -> bridge.v1.TransportEnvelope
-	6,  // 13: bridge.v1.StreamRequest.register:type_name -> bridge.v1.RegisterFrame
-	7,  // 14: bridge.v1.StreamRequest.ingress:type_name -> bridge.v1.IngressFrame
-	10, // 15: bridge.v1.StreamRequest.ack:type_name -> bridge.v1.AckFrame
-	11, // 16: bridge.v1.StreamRequest.heartbeat:type_name -> bridge.v1.HeartbeatFrame
-	8,  // 17: bridge.v1.StreamResponse.deliver:type_name -> bridge.v1.DeliverFrame
-	9,  // 18: bridge.v1.StreamResponse.broadcast:type_name -> bridge.v1.BroadcastFrame
-	11, // 19: bridge.v1.StreamResponse.heartbeat:type_name -> bridge.v1.HeartbeatFrame
-	12, // 20: bridge.v1.SidecarBridge.Stream:input_type -> bridge.v1.StreamRequest
-	13, // 21: bridge.v1.SidecarBridge.Stream:output_type -> bridge.v1.StreamResponse
-	21, // [21:22] is the sub-list for method output_type
-	20, // [20:21] is the sub-list for method input_type
-	20, // [20:20] is the sub-list for extension type_name
-	20, // [20:20] is the sub-list for extension extendee
-	0,  // [0:20] is the sub-list for field type_name
+	2,  // 0: bridge.v1.Payload.text:type_name -> bridge.v1.TextPayload
+	3,  // 1: bridge.v1.Payload.audio:type_name -> bridge.v1.AudioPayload
+	4,  // 2: bridge.v1.Payload.binary:type_name -> bridge.v1.BinaryPayload
+	5,  // 3: bridge.v1.Message.payload:type_name -> bridge.v1.Payload
+	25, // 4: bridge.v1.Message.metadata:type_name -> google.protobuf.Struct
+	6,  // 5: bridge.v1.Message.error:type_name -> bridge.v1.ErrorPayload
+	26, // 6: bridge.v1.Message.timestamp:type_name -> google.protobuf.Timestamp
+	25, // 7: bridge.v1.Message.extras:type_name -> google.protobuf.Struct
+	7,  // 8: bridge.v1.TransportEnvelope.message:type_name -> bridge.v1.Message
+	22, // 9: bridge.v1.TransportEnvelope.attributes:type_name -> bridge.v1.TransportEnvelope.AttributesEntry
+	26, // 10: bridge.v1.TransportEnvelope.created_at:type_name -> google.protobuf.Timestamp
+	8,  // 11: bridge.v1.IngressFrame.envelope:type_name -> bridge.v1.TransportEnvelope
+	8,  // 12: bridge.v1.DeliverFrame.envelope:type_name -> bridge.v1.TransportEnvelope
+	8,  // 13: bridge.v1.BroadcastFrame.envelope:type_name -> bridge.v1.TransportEnvelope
+	0,  // 14: bridge.v1.ControlFrame.command:type_name -> bridge.v1.ControlCommand
+	23, // 15: bridge.v1.ControlFrame.params:type_name -> bridge.v1.ControlFrame.ParamsEntry
+	1,  // 16: bridge.v1.ConnectionEventFrame.type:type_name -> bridge.v1.ConnectionEventType
+	24, // 17: bridge.v1.ConnectionEventFrame.attributes:type_name -> bridge.v1.ConnectionEventFrame.AttributesEntry
+	26, // 18: bridge.v1.ConnectionEventFrame.occurred_at:type_name -> google.protobuf.Timestamp
+	9,  // 19: bridge.v1.StreamRequest.register:type_name -> bridge.v1.RegisterFrame
+	10, // 20: bridge.v1.StreamRequest.ingress:type_name -> bridge.v1.IngressFrame
+	13, // 21: bridge.v1.StreamRequest.ack:type_name -> bridge.v1.AckFrame
+	14, // 22: bridge.v1.StreamRequest.heartbeat:type_name -> bridge.v1.HeartbeatFrame
+	15, // 23: bridge.v1.StreamRequest.chunk:type_name -> bridge.v1.ChunkFrame
+	17, // 24: bridge.v1.StreamRequest.control_result:type_name -> bridge.v1.ControlResultFrame
+	18, // 25: bridge.v1.StreamRequest.connection_event:type_name -> bridge.v1.ConnectionEventFrame
+	11, // 26: bridge.v1.StreamResponse.deliver:type_name -> bridge.v1.DeliverFrame
+	12, // 27: bridge.v1.StreamResponse.broadcast:type_name -> bridge.v1.BroadcastFrame
+	14, // 28: bridge.v1.StreamResponse.heartbeat:type_name -> bridge.v1.HeartbeatFrame
+	15, // 29: bridge.v1.StreamResponse.chunk:type_name -> bridge.v1.ChunkFrame
+	16, // 30: bridge.v1.StreamResponse.control:type_name -> bridge.v1.ControlFrame
+	26, // 31: bridge.v1.RecordedFrame.recorded_at:type_name -> google.protobuf.Timestamp
+	19, // 32: bridge.v1.RecordedFrame.request:type_name -> bridge.v1.StreamRequest
+	20, // 33: bridge.v1.RecordedFrame.response:type_name -> bridge.v1.StreamResponse
+	19, // 34: bridge.v1.SidecarBridge.Stream:input_type -> bridge.v1.StreamRequest
+	20, // 35: bridge.v1.SidecarBridge.Stream:output_type -> bridge.v1.StreamResponse
+	35, // [35:36] is the sub-list for method output_type
+	34, // [34:35] is the sub-list for method input_type
+	34, // [34:34] is the sub-list for extension type_name
+	34, // [34:34] is the sub-list for extension extendee
+	0,  // [0:34] is the sub-list for field type_name
 }
 
 func init() { file_bridge_v1_bridge_proto_init() }
@@ -1199,29 +2044,39 @@ func file_bridge_v1_bridge_proto_init() {
 	if File_bridge_v1_bridge_proto != nil {
 		return
 	}
-	file_bridge_v1_bridge_proto_msgTypes[12].OneofWrappers = []any{
+	file_bridge_v1_bridge_proto_msgTypes[17].OneofWrappers = []any{
 		(*StreamRequest_Register)(nil),
 		(*StreamRequest_Ingress)(nil),
 		(*StreamRequest_Ack)(nil),
 		(*StreamRequest_Heartbeat)(nil),
+		(*StreamRequest_Chunk)(nil),
+		(*StreamRequest_ControlResult)(nil),
+		(*StreamRequest_ConnectionEvent)(nil),
 	}
-	file_bridge_v1_bridge_proto_msgTypes[13].OneofWrappers = []any{
+	file_bridge_v1_bridge_proto_msgTypes[18].OneofWrappers = []any{
 		(*StreamResponse_Deliver)(nil),
 		(*StreamResponse_Broadcast)(nil),
 		(*StreamResponse_Heartbeat)(nil),
+		(*StreamResponse_Chunk)(nil),
+		(*StreamResponse_Control)(nil),
+	}
+	file_bridge_v1_bridge_proto_msgTypes[19].OneofWrappers = []any{
+		(*RecordedFrame_Request)(nil),
+		(*RecordedFrame_Response)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bridge_v1_bridge_proto_rawDesc), len(file_bridge_v1_bridge_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   15,
+			NumEnums:      2,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_bridge_v1_bridge_proto_goTypes,
 		DependencyIndexes: file_bridge_v1_bridge_proto_depIdxs,
+		EnumInfos:         file_bridge_v1_bridge_proto_enumTypes,
 		MessageInfos:      file_bridge_v1_bridge_proto_msgTypes,
 	}.Build()
 	File_bridge_v1_bridge_proto = out.File
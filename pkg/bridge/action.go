@@ -0,0 +1,204 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/Goden-Gun/transport-lib/pkg/codes"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+// ErrUnknownAction indicates no handler is registered for the message action.
+var ErrUnknownAction = errors.New("unknown action")
+
+// ActionHandler handles one ingress envelope for a registered action.
+type ActionHandler func(ctx context.Context, session Session, env *envelope.TransportEnvelope) error
+
+// ActionFunc is a typed action implementation used with HandleAction.
+type ActionFunc[Req, Resp any] func(ctx context.Context, session Session, env *envelope.TransportEnvelope, req Req) (Resp, error)
+
+// Validator is implemented by request types that check their own fields.
+// The error text is returned to the client as ErrorPayload.details.
+type Validator interface {
+	Validate() error
+}
+
+// HandleAction adapts a typed function into an ActionHandler.
+//
+// The request is decoded from Message.extras, or from a JSON text payload when
+// extras are empty; Message.metadata fields fill in keys the body lacks.
+// Proto messages are decoded with protojson, other types with encoding/json.
+// Numbers in extras and metadata are float64 (google.protobuf.Struct), so
+// integers above 2^53 must be sent as strings there (e.g. a ",string" json
+// tag, or int64 proto fields, which protojson accepts either way); a JSON
+// text payload keeps them exact. Requests implementing Validator are validated. Decode and validation
+// failures are returned wrapped in codes.ErrInvalidPayload.
+//
+// A non-nil response is encoded into the extras of a Deliver reply to the
+// originating connection, with the same float64 limit on numbers (int64
+// proto fields are encoded as strings); a nil pointer response sends no
+// reply.
+func HandleAction[Req, Resp any](fn ActionFunc[Req, Resp]) ActionHandler {
+	return func(ctx context.Context, session Session, env *envelope.TransportEnvelope) error {
+		req, err := decodeAction[Req](env.GetMessage())
+		if err != nil {
+			return codes.Wrap(codes.ErrInvalidPayload, err)
+		}
+		if v, ok := any(req).(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return codes.WithDetails(codes.Wrap(codes.ErrInvalidPayload, err), err.Error())
+			}
+		}
+		resp, err := fn(ctx, session, env, req)
+		if err != nil {
+			return err
+		}
+		if isNil(resp) {
+			return nil
+		}
+		extras, err := encodeAction(resp)
+		if err != nil {
+			return fmt.Errorf("encode %s response: %w", env.GetMessage().GetAction(), err)
+		}
+		reply := replyTo(env, &envelope.Message{Extras: extras})
+		return session.SendDeliver(ctx, *reply)
+	}
+}
+
+// ActionRouter dispatches ingress envelopes by Message.action.
+type ActionRouter struct {
+	mu       sync.RWMutex
+	handlers map[string]ActionHandler
+}
+
+// NewActionRouter creates an empty router.
+func NewActionRouter() *ActionRouter {
+	return &ActionRouter{handlers: map[string]ActionHandler{}}
+}
+
+// Handle registers h for action, replacing any previous handler.
+func (r *ActionRouter) Handle(action string, h ActionHandler) {
+	r.mu.Lock()
+	r.handlers[action] = h
+	r.mu.Unlock()
+}
+
+// Dispatch runs the handler registered for env's action. Unknown actions
+// return ErrUnknownAction wrapped in codes.ErrInvalidPayload.
+func (r *ActionRouter) Dispatch(ctx context.Context, session Session, env *envelope.TransportEnvelope) error {
+	action := env.GetMessage().GetAction()
+	r.mu.RLock()
+	h, ok := r.handlers[action]
+	r.mu.RUnlock()
+	if !ok {
+		err := fmt.Errorf("%w: %q", ErrUnknownAction, action)
+		return codes.WithDetails(codes.Wrap(codes.ErrInvalidPayload, err), err.Error())
+	}
+	return h(ctx, session, env)
+}
+
+// decodeAction builds a Req from extras or a JSON text payload plus metadata.
+func decodeAction[Req any](msg *envelope.Message) (Req, error) {
+	var req Req
+	data, err := actionJSON(msg)
+	if err != nil {
+		return req, err
+	}
+	if t := reflect.TypeOf(req); t != nil && t.Kind() == reflect.Pointer {
+		// Allocate pointer request types such as *pb.CreateRoomRequest.
+		req = reflect.New(t.Elem()).Interface().(Req)
+		if pm, ok := any(req).(proto.Message); ok {
+			err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, pm)
+		} else {
+			err = json.Unmarshal(data, req)
+		}
+		return req, err
+	}
+	err = json.Unmarshal(data, &req)
+	return req, err
+}
+
+// actionJSON returns the request body merged with msg's metadata; body keys
+// win. Non-object bodies are returned without metadata.
+func actionJSON(msg *envelope.Message) ([]byte, error) {
+	body, err := actionBody(msg)
+	if err != nil {
+		return nil, err
+	}
+	meta := msg.GetMetadata().GetFields()
+	if len(meta) == 0 {
+		return body, nil
+	}
+	// RawMessage values leave body fields as encoded. Only a JSON text
+	// payload keeps numbers exact: extras and metadata are structpb values,
+	// already float64.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return body, nil
+	}
+	for key, value := range meta {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		raw, err := protojson.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = raw
+	}
+	return json.Marshal(fields)
+}
+
+func actionBody(msg *envelope.Message) ([]byte, error) {
+	if extras := msg.GetExtras(); len(extras.GetFields()) > 0 {
+		return protojson.Marshal(extras)
+	}
+	if text := bytes.TrimSpace([]byte(msg.GetPayload().GetText().GetContent())); len(text) > 0 && json.Valid(text) {
+		return text, nil
+	}
+	return []byte("{}"), nil
+}
+
+// encodeAction converts resp into extras; non-object JSON is stored under "data".
+func encodeAction(resp any) (*structpb.Struct, error) {
+	var data []byte
+	var err error
+	if pm, ok := resp.(proto.Message); ok {
+		data, err = protojson.Marshal(pm)
+	} else {
+		data, err = json.Marshal(resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, out); err == nil {
+		return out, nil
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(map[string]any{"data": value})
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
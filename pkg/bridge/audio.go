@@ -0,0 +1,530 @@
+package bridge
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+var (
+	// ErrAudioTimeout indicates an audio stream was abandoned before is_last arrived.
+	ErrAudioTimeout = errors.New("audio stream timed out")
+	// ErrAudioAborted indicates an audio stream was closed by the consumer or reassembler.
+	ErrAudioAborted = errors.New("audio stream aborted")
+	// ErrNotAudio indicates the envelope carries no audio payload.
+	ErrNotAudio = errors.New("envelope has no audio payload")
+	// ErrAudioEncoding indicates audio data without a supported AttrAudioEncoding.
+	ErrAudioEncoding = errors.New("unsupported audio data encoding")
+)
+
+// AttrAudioEncoding is the envelope attribute naming how AudioPayload.data is
+// encoded; DecodeAudio requires it unless the chunk carries raw bytes.
+const AttrAudioEncoding = "audio_encoding"
+
+// AudioEncodingBase64 marks AudioPayload.data as standard base64.
+const AudioEncodingBase64 = "base64"
+
+// AudioOptions tune AudioReassembler behaviour.
+type AudioOptions struct {
+	// KeyFunc groups chunks into streams; defaults to AudioStreamKey.
+	KeyFunc func(env *envelope.TransportEnvelope) string
+	// Decode converts a chunk into raw bytes; defaults to DecodeAudio.
+	Decode func(env *envelope.TransportEnvelope) ([]byte, error)
+	// FirstSeq is the sequence number of the first chunk of a stream (default 0).
+	FirstSeq int64
+	// GapTimeout is how long a missing chunk is awaited before it is skipped (default 2s).
+	GapTimeout time.Duration
+	// IdleTimeout closes streams that receive nothing for this long (default 30s).
+	IdleTimeout time.Duration
+	// MaxPending bounds out-of-order chunks held per stream; overflow skips the gap (default 256).
+	MaxPending int
+	// OnGap is invoked when chunks [from, to) are skipped.
+	OnGap func(key string, from, to int64)
+}
+
+// AudioChunk is an in-order chunk of an audio stream.
+type AudioChunk struct {
+	Seq      int64
+	Codec    string
+	Data     []byte
+	IsLast   bool
+	Envelope *envelope.TransportEnvelope
+}
+
+// AudioStreamKey groups chunks by connection plus conversation_id, falling back
+// to request_id when the message has no conversation.
+func AudioStreamKey(env *envelope.TransportEnvelope) string {
+	msg := env.GetMessage()
+	id := msg.GetConversationId()
+	if id == "" {
+		id = msg.GetRequestId()
+	}
+	return env.GetConnectionId() + "/" + id
+}
+
+// DecodeAudio returns the raw chunk bytes when set. Otherwise data is decoded
+// as named by the AttrAudioEncoding attribute; data without it is rejected
+// with ErrAudioEncoding rather than guessed at.
+func DecodeAudio(env *envelope.TransportEnvelope) ([]byte, error) {
+	audio := env.GetMessage().GetPayload().GetAudio()
+	if audio == nil {
+		return nil, ErrNotAudio
+	}
+	if len(audio.GetRaw()) > 0 {
+		return audio.GetRaw(), nil
+	}
+	if audio.GetData() == "" {
+		return nil, nil
+	}
+	switch encoding := env.GetAttributes()[AttrAudioEncoding]; encoding {
+	case AudioEncodingBase64:
+		return base64.StdEncoding.DecodeString(audio.GetData())
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrAudioEncoding, encoding)
+	}
+}
+
+// AudioReassembler orders AudioPayload chunks by seq per stream, drops
+// duplicates, skips gaps that outlive GapTimeout and expires idle streams.
+type AudioReassembler struct {
+	opts AudioOptions
+
+	mu      sync.Mutex
+	streams map[string]*AudioStream
+	// finished remembers recently completed streams so late duplicates are dropped.
+	finished map[string]finishedStream
+
+	newCh chan *AudioStream
+	// watched is set once Streams was called; Push then waits for new streams to be taken.
+	watched  atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+type finishedStream struct {
+	lastSeq int64
+	at      time.Time
+}
+
+// NewAudioReassembler creates a reassembler and starts its expiry loop.
+func NewAudioReassembler(opts AudioOptions) *AudioReassembler {
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = AudioStreamKey
+	}
+	if opts.Decode == nil {
+		opts.Decode = DecodeAudio
+	}
+	if opts.GapTimeout <= 0 {
+		opts.GapTimeout = 2 * time.Second
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = 30 * time.Second
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = 256
+	}
+	r := &AudioReassembler{
+		opts:     opts,
+		streams:  map[string]*AudioStream{},
+		finished: map[string]finishedStream{},
+		newCh:    make(chan *AudioStream, 64),
+		stop:     make(chan struct{}),
+	}
+	go r.expireLoop()
+	return r
+}
+
+// Streams yields each new audio stream once. Reading it is optional, but
+// once Streams was called every new stream must be received: Push waits for
+// it (see Push).
+func (r *AudioReassembler) Streams() <-chan *AudioStream {
+	r.watched.Store(true)
+	return r.newCh
+}
+
+// Push feeds a chunk into its stream and returns that stream; created reports
+// whether this chunk opened it. Duplicate and late chunks return a nil stream.
+// When Streams is in use and its buffer is full, Push waits for a new stream
+// to be received; if ctx ends first the stream is aborted and ctx's error
+// returned, so the caller can nack the chunk.
+func (r *AudioReassembler) Push(ctx context.Context, env *envelope.TransportEnvelope) (stream *AudioStream, created bool, err error) {
+	audio := env.GetMessage().GetPayload().GetAudio()
+	if audio == nil {
+		return nil, false, ErrNotAudio
+	}
+	data, err := r.opts.Decode(env)
+	if err != nil {
+		return nil, false, err
+	}
+	chunk := &AudioChunk{Seq: audio.GetSeq(), Codec: audio.GetCodec(), Data: data, IsLast: audio.GetIsLast(), Envelope: env}
+	key := r.opts.KeyFunc(env)
+
+	r.mu.Lock()
+	stream, ok := r.streams[key]
+	if !ok {
+		if fin, done := r.finished[key]; done && chunk.Seq <= fin.lastSeq && chunk.Seq != r.opts.FirstSeq {
+			r.mu.Unlock()
+			return nil, false, nil
+		}
+		delete(r.finished, key)
+		stream = newAudioStream(key, env.GetConnectionId(), r.opts.FirstSeq)
+		r.streams[key] = stream
+		created = true
+	}
+	r.mu.Unlock()
+
+	if created && r.watched.Load() {
+		select {
+		case r.newCh <- stream:
+		case <-r.stop:
+		case <-ctx.Done():
+			stream.finish(ErrAudioAborted)
+			r.retire(stream)
+			return nil, false, ctx.Err()
+		}
+	}
+	if !stream.push(chunk, r.opts.MaxPending, r.opts.OnGap) {
+		return nil, created, nil
+	}
+	if stream.isClosed() {
+		r.retire(stream)
+	}
+	return stream, created, nil
+}
+
+// Abort closes the stream for key with ErrAudioAborted, e.g. when the
+// connection that produced it went away.
+func (r *AudioReassembler) Abort(key string) {
+	r.mu.Lock()
+	stream := r.streams[key]
+	r.mu.Unlock()
+	if stream == nil {
+		return
+	}
+	stream.finish(ErrAudioAborted)
+	r.retire(stream)
+}
+
+// AbortConnection aborts every stream produced by connectionID, e.g. on a
+// ConnectionClosed event, and returns how many were aborted. Streams are
+// matched by the connection of their first chunk, whatever the KeyFunc.
+func (r *AudioReassembler) AbortConnection(connectionID string) int {
+	var streams []*AudioStream
+	r.mu.Lock()
+	for _, stream := range r.streams {
+		if stream.connID == connectionID {
+			streams = append(streams, stream)
+		}
+	}
+	r.mu.Unlock()
+	for _, stream := range streams {
+		stream.finish(ErrAudioAborted)
+		r.retire(stream)
+	}
+	return len(streams)
+}
+
+// Close aborts all streams and stops the expiry loop.
+func (r *AudioReassembler) Close() error {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		r.mu.Lock()
+		streams := r.streams
+		r.streams = map[string]*AudioStream{}
+		r.mu.Unlock()
+		for _, s := range streams {
+			s.finish(ErrAudioAborted)
+		}
+	})
+	return nil
+}
+
+func (r *AudioReassembler) retire(stream *AudioStream) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.streams[stream.key] != stream {
+		return
+	}
+	delete(r.streams, stream.key)
+	r.finished[stream.key] = finishedStream{lastSeq: stream.lastSeq(), at: time.Now()}
+}
+
+func (r *AudioReassembler) expireLoop() {
+	interval := r.opts.GapTimeout / 2
+	if interval < 50*time.Millisecond {
+		interval = 50 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			r.mu.Lock()
+			streams := make([]*AudioStream, 0, len(r.streams))
+			for _, s := range r.streams {
+				streams = append(streams, s)
+			}
+			for key, fin := range r.finished {
+				if now.Sub(fin.at) > r.opts.IdleTimeout {
+					delete(r.finished, key)
+				}
+			}
+			r.mu.Unlock()
+			for _, s := range streams {
+				if s.expire(now, r.opts.GapTimeout, r.opts.IdleTimeout, r.opts.OnGap) {
+					r.retire(s)
+				}
+			}
+		}
+	}
+}
+
+// AudioStream is an ordered view of one voice stream. It can be consumed with
+// Next or as an io.Reader over the decoded chunk bytes.
+type AudioStream struct {
+	key    string
+	connID string
+
+	mu         sync.Mutex
+	next       int64
+	pending    map[int64]*AudioChunk
+	queue      []*AudioChunk
+	gapSince   time.Time
+	lastActive time.Time
+	closed     bool
+	err        error
+	duplicates int
+	gaps       int
+
+	notify chan struct{}
+	done   chan struct{}
+
+	readBuf []byte
+}
+
+func newAudioStream(key, connID string, firstSeq int64) *AudioStream {
+	return &AudioStream{
+		key:        key,
+		connID:     connID,
+		next:       firstSeq,
+		pending:    map[int64]*AudioChunk{},
+		lastActive: time.Now(),
+		notify:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// Key returns the stream key.
+func (s *AudioStream) Key() string {
+	return s.key
+}
+
+// Done is closed once the stream has ended (is_last, timeout or abort).
+func (s *AudioStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the terminal error; nil while open or after a clean is_last.
+func (s *AudioStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Stats reports dropped duplicates and skipped gaps so far.
+func (s *AudioStream) Stats() (duplicates, gaps int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.duplicates, s.gaps
+}
+
+// Next returns the next in-order chunk. It returns io.EOF after the last chunk
+// of a complete stream, or the terminal error of an abandoned one.
+func (s *AudioStream) Next(ctx context.Context) (*AudioChunk, error) {
+	for {
+		s.mu.Lock()
+		if len(s.queue) > 0 {
+			chunk := s.queue[0]
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			return chunk, nil
+		}
+		if s.closed {
+			err := s.err
+			s.mu.Unlock()
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.notify:
+		case <-s.done:
+		}
+	}
+}
+
+// Read implements io.Reader over the decoded audio bytes.
+func (s *AudioStream) Read(p []byte) (int, error) {
+	for len(s.readBuf) == 0 {
+		chunk, err := s.Next(context.Background())
+		if err != nil {
+			return 0, err
+		}
+		s.readBuf = chunk.Data
+	}
+	n := copy(p, s.readBuf)
+	s.readBuf = s.readBuf[n:]
+	return n, nil
+}
+
+// Close aborts the stream.
+func (s *AudioStream) Close() error {
+	s.finish(ErrAudioAborted)
+	return nil
+}
+
+// push returns false when the chunk was dropped as a duplicate or late arrival.
+func (s *AudioStream) push(chunk *AudioChunk, maxPending int, onGap func(string, int64, int64)) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false
+	}
+	s.lastActive = time.Now()
+	if chunk.Seq < s.next {
+		s.duplicates++
+		s.mu.Unlock()
+		return false
+	}
+	if _, dup := s.pending[chunk.Seq]; dup {
+		s.duplicates++
+		s.mu.Unlock()
+		return false
+	}
+	s.pending[chunk.Seq] = chunk
+	var skipped [][2]int64
+	if len(s.pending) > maxPending {
+		skipped = append(skipped, s.skipGapLocked())
+	}
+	s.drainLocked()
+	s.mu.Unlock()
+	s.signal()
+	for _, gap := range skipped {
+		if onGap != nil {
+			onGap(s.key, gap[0], gap[1])
+		}
+	}
+	return true
+}
+
+// expire skips stale gaps and times out idle streams; it reports whether the stream ended.
+func (s *AudioStream) expire(now time.Time, gapTimeout, idleTimeout time.Duration, onGap func(string, int64, int64)) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return true
+	}
+	var gap *[2]int64
+	if len(s.pending) > 0 && !s.gapSince.IsZero() && now.Sub(s.gapSince) >= gapTimeout {
+		g := s.skipGapLocked()
+		gap = &g
+		s.drainLocked()
+	}
+	idle := now.Sub(s.lastActive) >= idleTimeout
+	s.mu.Unlock()
+	if gap != nil {
+		s.signal()
+		if onGap != nil {
+			onGap(s.key, gap[0], gap[1])
+		}
+	}
+	if idle {
+		s.finish(ErrAudioTimeout)
+		return true
+	}
+	return s.isClosed()
+}
+
+// skipGapLocked advances next to the lowest pending seq and returns the skipped range.
+func (s *AudioStream) skipGapLocked() [2]int64 {
+	lowest := int64(-1)
+	for seq := range s.pending {
+		if lowest < 0 || seq < lowest {
+			lowest = seq
+		}
+	}
+	from := s.next
+	s.next = lowest
+	s.gaps++
+	return [2]int64{from, lowest}
+}
+
+func (s *AudioStream) drainLocked() {
+	for {
+		chunk, ok := s.pending[s.next]
+		if !ok {
+			break
+		}
+		delete(s.pending, s.next)
+		s.queue = append(s.queue, chunk)
+		s.next++
+		if chunk.IsLast {
+			s.closeLocked(nil)
+			return
+		}
+	}
+	if len(s.pending) == 0 {
+		s.gapSince = time.Time{}
+	} else if s.gapSince.IsZero() {
+		s.gapSince = time.Now()
+	}
+}
+
+func (s *AudioStream) finish(err error) {
+	s.mu.Lock()
+	s.closeLocked(err)
+	s.mu.Unlock()
+}
+
+func (s *AudioStream) closeLocked(err error) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.err = err
+	s.pending = map[int64]*AudioChunk{}
+	close(s.done)
+}
+
+func (s *AudioStream) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *AudioStream) lastSeq() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next - 1
+}
+
+func (s *AudioStream) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,211 @@
+package bridge_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+const (
+	benchConnID   = "bench-conn"
+	benchPayload  = 1024
+	benchWaitStep = 20 * time.Millisecond
+)
+
+var benchCompressions = []string{bridge.CompressionNone, bridge.CompressionGzip, bridge.CompressionZstd}
+
+var benchAddrSeq atomic.Int64
+
+// benchPair is a worker and one connected client over inproc.
+type benchPair struct {
+	client  bridge.Client
+	session bridge.Session
+}
+
+// benchWorker hands ingress to onIngress and publishes the session.
+type benchWorker struct {
+	sessions  chan bridge.Session
+	onIngress func(ctx context.Context, s bridge.Session, env envelope.TransportEnvelope) error
+}
+
+func (w *benchWorker) OnRegister(ctx context.Context, s bridge.Session, meta bridge.RegisterMeta) error {
+	w.sessions <- s
+	return nil
+}
+
+func (w *benchWorker) OnIngress(ctx context.Context, s bridge.Session, env envelope.TransportEnvelope) error {
+	return w.onIngress(ctx, s, env)
+}
+
+func (w *benchWorker) OnAck(ctx context.Context, s bridge.Session, ack bridge.Ack) error {
+	return nil
+}
+
+func (w *benchWorker) OnHeartbeat(ctx context.Context, s bridge.Session, nonce string) error {
+	return nil
+}
+
+func (w *benchWorker) OnClose(ctx context.Context, s bridge.Session) error {
+	return nil
+}
+
+// newBenchPair starts a worker and a connected client; both are closed when b ends.
+func newBenchPair(b *testing.B, compression string,
+	onIngress func(context.Context, bridge.Session, envelope.TransportEnvelope) error,
+	onDeliver func(*bridge.Delivery),
+) *benchPair {
+	b.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Cleanup(cancel)
+	addr := fmt.Sprintf("inproc://bridge-bench-%d", benchAddrSeq.Add(1))
+	opts := bridge.Options{Address: addr, Insecure: true, NodeID: "bench", Namespace: "bench", Compression: compression}
+	srv, err := bridge.NewServer(opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = srv.Close() })
+	w := &benchWorker{sessions: make(chan bridge.Session, 1), onIngress: onIngress}
+	go func() { _ = srv.Serve(ctx, w) }()
+
+	client, err := bridge.NewClient(opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = client.Close() })
+	if err := client.Start(ctx); err != nil {
+		b.Fatal(err)
+	}
+	err = client.HandleDeliver(func(ctx context.Context, d *bridge.Delivery) error {
+		onDeliver(d)
+		return nil
+	}, bridge.HandleOptions{AckTimeout: -1})
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := waitStarted(ctx, client); err != nil {
+		b.Fatal(err)
+	}
+	return &benchPair{client: client, session: <-w.sessions}
+}
+
+// waitStarted blocks until the client's stream is up; Start connects in the background.
+func waitStarted(ctx context.Context, client bridge.Client) error {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		err := client.PublishConnectionEvent(ctx, bridge.ConnectionEvent{Type: bridge.ConnectionOpened, ConnectionID: benchConnID})
+		if !errors.Is(err, bridge.ErrNotStarted) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(benchWaitStep)
+	}
+}
+
+func benchEnvelope() envelope.TransportEnvelope {
+	return envelope.TransportEnvelope{
+		ConnectionId: benchConnID,
+		Message: &envelope.Message{
+			Action:    "bench.echo",
+			RequestId: "r",
+			Payload:   &envelope.Payload{Text: &envelope.TextPayload{Content: strings.Repeat("x", benchPayload)}},
+		},
+	}
+}
+
+func compressionName(compression string) string {
+	if compression == bridge.CompressionNone {
+		return "none"
+	}
+	return compression
+}
+
+func discardIngress(context.Context, bridge.Session, envelope.TransportEnvelope) error { return nil }
+
+func discardDeliver(*bridge.Delivery) {}
+
+func echoIngress(ctx context.Context, s bridge.Session, env envelope.TransportEnvelope) error {
+	return s.SendDeliver(ctx, envelope.TransportEnvelope{
+		ConnectionId:        env.GetConnectionId(),
+		TargetConnectionIds: []string{env.GetConnectionId()},
+		Message:             env.GetMessage(),
+	})
+}
+
+// BenchmarkPublishIngress measures the client send path from one goroutine.
+func BenchmarkPublishIngress(b *testing.B) {
+	for _, compression := range benchCompressions {
+		b.Run("compression="+compressionName(compression), func(b *testing.B) {
+			p := newBenchPair(b, compression, discardIngress, discardDeliver)
+			ctx := context.Background()
+			env := benchEnvelope()
+			b.SetBytes(benchPayload)
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := p.client.PublishIngress(ctx, env); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPublishIngressParallel measures contention on the stream's send lock.
+func BenchmarkPublishIngressParallel(b *testing.B) {
+	p := newBenchPair(b, bridge.CompressionNone, discardIngress, discardDeliver)
+	ctx := context.Background()
+	b.SetBytes(benchPayload)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		env := benchEnvelope()
+		for pb.Next() {
+			if err := p.client.PublishIngress(ctx, env); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkSendDeliver measures worker → client deliveries through Session.SendDeliver.
+func BenchmarkSendDeliver(b *testing.B) {
+	for _, compression := range benchCompressions {
+		b.Run("compression="+compressionName(compression), func(b *testing.B) {
+			p := newBenchPair(b, compression, discardIngress, discardDeliver)
+			ctx := context.Background()
+			env := benchEnvelope()
+			env.TargetConnectionIds = []string{benchConnID}
+			b.SetBytes(benchPayload)
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := p.session.SendDeliver(ctx, env); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkEchoRoundTrip publishes and waits for the echo, one message in flight.
+func BenchmarkEchoRoundTrip(b *testing.B) {
+	echoed := make(chan struct{}, 1)
+	p := newBenchPair(b, bridge.CompressionNone, echoIngress, func(*bridge.Delivery) { echoed <- struct{}{} })
+	ctx := context.Background()
+	env := benchEnvelope()
+	b.SetBytes(benchPayload)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := p.client.PublishIngress(ctx, env); err != nil {
+			b.Fatal(err)
+		}
+		<-echoed
+	}
+}
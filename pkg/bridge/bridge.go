@@ -2,17 +2,33 @@ package bridge
 
 import (
 	"context"
+	"net"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+
 	"github.com/Goden-Gun/transport-lib/pkg/envelope"
 )
 
 // Client represents a bidirectional transport session.
+//
+// SubscribeDeliver/SubscribeBroadcast return a channel per call that is closed
+// when ctx is done or the client closes; subscribers and handlers compete for
+// frames, each frame is consumed once.
 type Client interface {
 	Start(ctx context.Context) error
 	PublishIngress(ctx context.Context, env envelope.TransportEnvelope) error
+	// PublishConnectionEvent reports a client connection opening, closing or changing identity.
+	PublishConnectionEvent(ctx context.Context, event ConnectionEvent) error
 	SubscribeDeliver(ctx context.Context) (<-chan *Delivery, error)
 	SubscribeBroadcast(ctx context.Context) (<-chan *BroadcastDelivery, error)
+	// HandleDeliver and HandleBroadcast consume frames with a callback instead of a channel.
+	HandleDeliver(fn DeliverFunc, opts HandleOptions) error
+	HandleBroadcast(fn BroadcastFunc, opts HandleOptions) error
+	// SubscribeControl and HandleControl receive worker commands (kick, close user, throttle).
+	SubscribeControl(ctx context.Context) (<-chan *ControlRequest, error)
+	HandleControl(fn ControlFunc, opts HandleOptions) error
 	Drain(ctx context.Context) error
 	Close() error
 }
@@ -23,7 +39,41 @@ type Server interface {
 	Close() error
 }
 
-// Handler handles inbound frames from sidecar nodes.
+// Embeddable is implemented by servers that can share a caller's listener
+// or gRPC server.
+type Embeddable interface {
+	// ServeListener serves on a caller-supplied listener instead of Options.Address.
+	ServeListener(ctx context.Context, lis net.Listener, handler Handler) error
+	// Register attaches the SidecarBridge service to an existing gRPC server.
+	Register(registrar grpc.ServiceRegistrar, handler Handler) error
+}
+
+// Dispatcher is implemented by servers that address sessions themselves.
+type Dispatcher interface {
+	// Deliver routes a user-targeted envelope to the sessions holding those users (requires Options.Presence).
+	Deliver(ctx context.Context, env *envelope.TransportEnvelope) (int, error)
+	// Broadcast sends env to every session (or opts.NodeIDs) and aggregates their acks.
+	Broadcast(ctx context.Context, env *envelope.TransportEnvelope, opts BroadcastOptions) (*BroadcastHandle, error)
+}
+
+// HealthChecker is implemented by servers whose health status follows
+// dependency checks.
+type HealthChecker interface {
+	// AddHealthCheck registers a dependency check that drives the health status; nil removes it.
+	AddHealthCheck(name string, check HealthCheck)
+}
+
+// WorkerServer is the server built by NewServer. Other Server
+// implementations may support any subset of the optional interfaces.
+type WorkerServer interface {
+	Server
+	Embeddable
+	Dispatcher
+	HealthChecker
+}
+
+// Handler handles inbound frames from sidecar nodes. Handlers may also
+// implement ConnectionEventHandler to receive connection lifecycle events.
 type Handler interface {
 	OnRegister(ctx context.Context, session Session, meta RegisterMeta) error
 	OnIngress(ctx context.Context, session Session, env envelope.TransportEnvelope) error
@@ -34,10 +84,27 @@ type Handler interface {
 
 // Session represents an active stream between sidecar and worker.
 type Session interface {
+	// SendDeliver writes env to this session's sidecar as is; target_user_ids
+	// are not looked up in Options.Presence. Use Server.Deliver to route
+	// user-targeted envelopes to the nodes holding those users.
 	SendDeliver(ctx context.Context, env envelope.TransportEnvelope) error
 	SendBroadcast(ctx context.Context, env envelope.TransportEnvelope) error
 	SendHeartbeat(ctx context.Context, nonce string) error
+	// SendControl sends a command to the sidecar and waits for its result.
+	SendControl(ctx context.Context, ctl Control) (ControlResult, error)
 	Metadata() RegisterMeta
+	// ID is unique per stream; a reconnecting sidecar gets a new one.
+	ID() string
+	// RemoteAddr is the sidecar's peer address, nil when unknown.
+	RemoteAddr() net.Addr
+	ConnectedAt() time.Time
+	// Context is cancelled when the stream ends.
+	Context() context.Context
+	// Set, Get and Delete manage per-session attributes; safe for concurrent use.
+	Set(key string, value any)
+	Get(key string) (any, bool)
+	Delete(key string)
+	// Close terminates the stream.
 	Close() error
 }
 
@@ -76,4 +143,72 @@ type Options struct {
 	EnableBackpressure      bool
 	MaxInFlightDeliver      int
 	GracefulShutdownTimeout time.Duration
+	// MaxFrameSize splits frames whose encoded size exceeds it into ChunkFrames (default 1 MiB).
+	MaxFrameSize int
+	// MaxChunkedSize bounds a reassembled chunked frame, and all frames being
+	// reassembled on a stream together (default 64 MiB).
+	MaxChunkedSize int
+	// ChunkTimeout resets the stream when a chunked frame is still incomplete
+	// after this long (default 30s).
+	ChunkTimeout time.Duration
+	// Compression enables stream compression: "" (off), "gzip" or "zstd". A client
+	// with it set opens the stream with the "bridge" content-subtype; the server
+	// answers such streams with its own setting and decodes either algorithm.
+	Compression string
+	// CompressionThreshold leaves frames smaller than this many bytes uncompressed
+	// (default 1024). Decompressed frames are limited to MaxRecvMsgSize.
+	CompressionThreshold int
+	// MaxSendMsgSize and MaxRecvMsgSize override gRPC message size limits (0 = gRPC defaults).
+	MaxSendMsgSize int
+	MaxRecvMsgSize int
+	// KeepaliveTime is the idle interval after which a keepalive ping is sent (0 = gRPC default).
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout is how long to wait for a ping ack before closing the connection.
+	KeepaliveTimeout time.Duration
+	// KeepalivePermitWithoutStream allows pings without active streams (client),
+	// or tolerates them (server).
+	KeepalivePermitWithoutStream bool
+	// KeepaliveMinTime is the minimum client ping interval the server tolerates
+	// (server only, default 10s).
+	KeepaliveMinTime time.Duration
+	// ServerOptions are appended to the options passed to grpc.NewServer.
+	ServerOptions []grpc.ServerOption
+	// DialOptions are appended to the options used to dial the bridge.
+	DialOptions []grpc.DialOption
+	// HealthServer is updated instead of registering a new grpc.health.v1 service,
+	// for servers shared with other services that already expose one.
+	HealthServer *health.Server
+	// HealthCheckInterval is how often AddHealthCheck checks run (default 10s).
+	HealthCheckInterval time.Duration
+	// EnableReflection registers the gRPC reflection service (server only).
+	EnableReflection bool
+	// Presence is kept up to date from connection events and heartbeats and used by Server.Deliver.
+	Presence Presence
+	// ControlTimeout bounds how long SendControl waits for a result (default 10s).
+	ControlTimeout time.Duration
+	// BroadcastAckTimeout bounds how long a broadcast waits for session acks (default 30s).
+	BroadcastAckTimeout time.Duration
+	// ReplayBufferSize is the number of Deliver/Broadcast frames the server retains
+	// per sidecar node, and of Ingress frames the client retains, to resend after
+	// a reconnect (default 1024, negative disables).
+	ReplayBufferSize int
+	// ReplayBufferTTL is how long a node's replay buffer is kept after its last
+	// session closed (default 5m); a node reconnecting later starts over.
+	ReplayBufferTTL time.Duration
+	// OrderedDelivery makes the server stamp a per-connection nfc_sequence on
+	// single-target Deliver envelopes and the client release them in order.
+	OrderedDelivery bool
+	// ReorderTimeout is how long the client waits for a missing sequence before
+	// skipping it (default 200ms).
+	ReorderTimeout time.Duration
+	// OnSequenceGap observes skipped sequences on the client; when nil they are logged.
+	OnSequenceGap func(SequenceGap)
+	// Recorder, when set, records every frame sent and received (see NewRecorder).
+	Recorder *Recorder
+	// OnBroadcastResult observes every settled broadcast, including Session.SendBroadcast ones.
+	OnBroadcastResult func(BroadcastResult)
+	// HandlerErrorPolicy decides whether handler errors close the stream (default ErrorPolicyReply).
+	HandlerErrorPolicy ErrorPolicy
+	// OnHandlerError observes non-fatal handler errors; when nil they are logged.
+	OnHandlerError func(ctx context.Context, session Session, err error)
 }
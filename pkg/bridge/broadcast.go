@@ -0,0 +1,347 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+const defaultBroadcastAckTimeout = 30 * time.Second
+
+// AttrBroadcastID is the envelope attribute Session.SendBroadcast uses as
+// broadcast_id when set; a uuid is used otherwise.
+const AttrBroadcastID = "broadcast_id"
+
+// BroadcastOptions configure Server.Broadcast.
+type BroadcastOptions struct {
+	// ID is the broadcast_id (default uuid).
+	ID string
+	// NodeIDs restricts the broadcast to these sidecar nodes (default all sessions).
+	NodeIDs []string
+	// AckTimeout overrides Options.BroadcastAckTimeout.
+	AckTimeout time.Duration
+}
+
+// BroadcastResult aggregates the acknowledgements of one broadcast.
+type BroadcastResult struct {
+	BroadcastID string
+	// Sessions is the number of sessions the broadcast was sent to.
+	Sessions int
+	// Acked lists session ids that acknowledged successfully.
+	Acked []string
+	// Failed maps session ids to the nack reason, send error or "session closed"
+	// (a closed session of a node with a replay buffer stays pending for its resume).
+	Failed map[string]string
+	// Pending lists session ids that had not answered when the broadcast timed out.
+	Pending []string
+	// TimedOut is set when AckTimeout elapsed before every session answered.
+	TimedOut bool
+}
+
+// Complete reports whether every session acknowledged successfully.
+func (r BroadcastResult) Complete() bool {
+	return len(r.Acked) == r.Sessions
+}
+
+// BroadcastHandle tracks an in-flight broadcast.
+type BroadcastHandle struct {
+	id     string
+	done   chan struct{}
+	result BroadcastResult
+}
+
+// ID returns the broadcast_id.
+func (h *BroadcastHandle) ID() string {
+	return h.id
+}
+
+// Done is closed once every session answered or the broadcast timed out.
+func (h *BroadcastHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the broadcast settles or ctx is done.
+func (h *BroadcastHandle) Wait(ctx context.Context) (BroadcastResult, error) {
+	select {
+	case <-h.done:
+		return h.result, nil
+	case <-ctx.Done():
+		return BroadcastResult{}, ctx.Err()
+	}
+}
+
+// Result returns the settled result; it is only meaningful after Done.
+func (h *BroadcastHandle) Result() BroadcastResult {
+	select {
+	case <-h.done:
+		return h.result
+	default:
+		return BroadcastResult{}
+	}
+}
+
+// broadcastTracker aggregates broadcast acks per broadcast_id.
+type broadcastTracker struct {
+	timeout  time.Duration
+	onResult func(BroadcastResult)
+
+	mu      sync.Mutex
+	pending map[string]*trackedBroadcast
+	// settled keeps finished ids for one ack timeout, so a late
+	// Session.SendBroadcast fan-out does not open a second broadcast.
+	settled map[string]time.Time
+}
+
+type trackedBroadcast struct {
+	handle *BroadcastHandle
+	// nodes maps every target session id to its node id.
+	nodes    map[string]string
+	waiting  map[string]bool
+	acked    []string
+	failed   map[string]string
+	deadline time.Time
+	timer    *time.Timer
+}
+
+func newBroadcastTracker(timeout time.Duration, onResult func(BroadcastResult)) *broadcastTracker {
+	if timeout <= 0 {
+		timeout = defaultBroadcastAckTimeout
+	}
+	return &broadcastTracker{timeout: timeout, onResult: onResult, pending: map[string]*trackedBroadcast{}, settled: map[string]time.Time{}}
+}
+
+// start registers a broadcast awaiting acks from targets. Starting an id
+// that is still pending adds the sessions to it (the Session.SendBroadcast
+// fan-out pattern) and returns the existing handle; its deadline moves to
+// the later of the two. A broadcast to no session finishes immediately.
+// start returns false, and tracks nothing, for an id that already settled.
+func (t *broadcastTracker) start(id string, targets []*session, timeout time.Duration) (*BroadcastHandle, bool) {
+	if timeout <= 0 {
+		timeout = t.timeout
+	}
+	deadline := time.Now().Add(timeout)
+	t.mu.Lock()
+	if _, ok := t.settled[id]; ok {
+		t.mu.Unlock()
+		return nil, false
+	}
+	tb := t.pending[id]
+	if tb == nil {
+		if len(targets) == 0 {
+			t.retire(id)
+			t.mu.Unlock()
+			tb = &trackedBroadcast{handle: &BroadcastHandle{id: id, done: make(chan struct{})}, failed: map[string]string{}}
+			t.finish(tb, false)
+			return tb.handle, true
+		}
+		tb = &trackedBroadcast{
+			handle:   &BroadcastHandle{id: id, done: make(chan struct{})},
+			nodes:    make(map[string]string, len(targets)),
+			waiting:  make(map[string]bool, len(targets)),
+			failed:   map[string]string{},
+			deadline: deadline,
+		}
+		t.pending[id] = tb
+		tb.timer = time.AfterFunc(timeout, func() { t.expire(id, tb) })
+	} else if deadline.After(tb.deadline) {
+		tb.deadline = deadline
+		tb.timer.Reset(timeout)
+	}
+	for _, sess := range targets {
+		if _, ok := tb.nodes[sess.id]; !ok {
+			tb.nodes[sess.id] = sess.meta.NodeID
+			tb.waiting[sess.id] = true
+			tb.handle.result.Sessions++
+		}
+	}
+	t.mu.Unlock()
+	return tb.handle, true
+}
+
+// retire moves id from pending to settled; the caller holds mu.
+func (t *broadcastTracker) retire(id string) {
+	delete(t.pending, id)
+	at := time.Now()
+	t.settled[id] = at
+	time.AfterFunc(t.timeout, func() {
+		t.mu.Lock()
+		if t.settled[id] == at {
+			delete(t.settled, id)
+		}
+		t.mu.Unlock()
+	})
+}
+
+// settle records the outcome of one session.
+func (t *broadcastTracker) settle(id, sessionID string, failed bool, reason string) {
+	t.mu.Lock()
+	tb := t.pending[id]
+	if tb == nil || !tb.waiting[sessionID] {
+		t.mu.Unlock()
+		return
+	}
+	delete(tb.waiting, sessionID)
+	if failed {
+		tb.failed[sessionID] = reason
+	} else {
+		tb.acked = append(tb.acked, sessionID)
+	}
+	finished := len(tb.waiting) == 0
+	if finished {
+		t.retire(id)
+	}
+	t.mu.Unlock()
+	if finished {
+		tb.timer.Stop()
+		t.finish(tb, false)
+	}
+}
+
+// sessionClosed fails every broadcast still waiting on sessionID. Sessions
+// of a node with a replay buffer stay waiting instead: the node's next
+// session is resent the frame and takes over through resumed, otherwise the
+// broadcast times out with the session pending.
+func (t *broadcastTracker) sessionClosed(sessionID string, resumable bool) {
+	if resumable {
+		return
+	}
+	t.mu.Lock()
+	var ids []string
+	for id, tb := range t.pending {
+		if tb.waiting[sessionID] {
+			ids = append(ids, id)
+		}
+	}
+	t.mu.Unlock()
+	for _, id := range ids {
+		t.settle(id, sessionID, true, "session closed")
+	}
+}
+
+// resumed hands the waiting slot of a previous session of sess's node over
+// to sess, which was resent broadcast id on resume and acks under its own
+// session id.
+func (t *broadcastTracker) resumed(id string, sess *session) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tb := t.pending[id]
+	if tb == nil {
+		return
+	}
+	if _, ok := tb.nodes[sess.id]; ok {
+		return
+	}
+	for sid := range tb.waiting {
+		if tb.nodes[sid] != sess.meta.NodeID {
+			continue
+		}
+		delete(tb.waiting, sid)
+		delete(tb.nodes, sid)
+		tb.waiting[sess.id] = true
+		tb.nodes[sess.id] = sess.meta.NodeID
+		return
+	}
+}
+
+// ack records an AckFrame carrying a broadcast_id.
+func (t *broadcastTracker) ack(sessionID string, ack Ack) {
+	if ack.Status == AckStatusNack {
+		reason := ack.Reason
+		if reason == "" {
+			reason = AckStatusNack
+		}
+		t.settle(ack.BroadcastID, sessionID, true, reason)
+		return
+	}
+	t.settle(ack.BroadcastID, sessionID, false, "")
+}
+
+// expire finishes tb as timed out, unless it settled meanwhile or a later
+// start extended its deadline.
+func (t *broadcastTracker) expire(id string, tb *trackedBroadcast) {
+	t.mu.Lock()
+	if t.pending[id] != tb {
+		t.mu.Unlock()
+		return
+	}
+	if remaining := time.Until(tb.deadline); remaining > 0 {
+		tb.timer.Reset(remaining)
+		t.mu.Unlock()
+		return
+	}
+	t.retire(id)
+	t.mu.Unlock()
+	t.finish(tb, true)
+}
+
+func (t *broadcastTracker) finish(tb *trackedBroadcast, timedOut bool) {
+	res := &tb.handle.result
+	res.BroadcastID = tb.handle.id
+	res.Acked = tb.acked
+	res.Failed = tb.failed
+	res.TimedOut = timedOut
+	for sid := range tb.waiting {
+		res.Pending = append(res.Pending, sid)
+	}
+	close(tb.handle.done)
+	if t.onResult != nil {
+		t.onResult(*res)
+	}
+}
+
+// Broadcast sends env to every session (or those of opts.NodeIDs) under one
+// broadcast_id and returns a handle aggregating their acks. Every target is
+// registered before the first frame is sent, so early acks cannot settle
+// the broadcast. Reusing the id of a broadcast that settled within the ack
+// timeout is an error.
+func (s *server) Broadcast(ctx context.Context, env *envelope.TransportEnvelope, opts BroadcastOptions) (*BroadcastHandle, error) {
+	if env == nil {
+		return nil, errors.New("envelope is required")
+	}
+	id := opts.ID
+	if id == "" {
+		id = uuid.NewString()
+	}
+	targets := s.sessionsForNodes(opts.NodeIDs)
+	handle, ok := s.broadcasts.start(id, targets, opts.AckTimeout)
+	if !ok {
+		return nil, fmt.Errorf("broadcast %s already settled", id)
+	}
+	if len(targets) == 0 {
+		return handle, nil
+	}
+	out := proto.Clone(env).(*envelope.TransportEnvelope)
+	envelope.NormalizeEnvelope(out)
+	resp := &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Broadcast{Broadcast: &bridgepb.BroadcastFrame{Envelope: out, BroadcastId: id}}}
+	for _, sess := range targets {
+		if err := sess.send(ctx, resp); err != nil {
+			s.broadcasts.settle(id, sess.id, true, err.Error())
+		}
+	}
+	return handle, nil
+}
+
+// sessionsForNodes returns the sessions of nodeIDs, or all sessions when empty.
+func (s *server) sessionsForNodes(nodeIDs []string) []*session {
+	want := make(map[string]bool, len(nodeIDs))
+	for _, id := range nodeIDs {
+		want[id] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		if len(want) == 0 || want[sess.meta.NodeID] {
+			out = append(out, sess)
+		}
+	}
+	return out
+}
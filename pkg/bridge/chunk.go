@@ -0,0 +1,183 @@
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+)
+
+const (
+	defaultMaxFrameSize   = 1 << 20
+	defaultMaxChunkedSize = 64 << 20
+	defaultChunkTimeout   = 30 * time.Second
+	// maxPartialFrames caps the frames being reassembled per stream. Senders
+	// write the chunks of a frame back to back, so more than one is only
+	// seen from a broken peer.
+	maxPartialFrames = 4
+)
+
+var (
+	// ErrChunkedTooLarge indicates a chunked frame exceeds MaxChunkedSize.
+	ErrChunkedTooLarge = errors.New("chunked frame exceeds max size")
+	// ErrCorruptFrame indicates a chunked frame could not be reassembled or
+	// decoded; the frame is lost and the stream is reset.
+	ErrCorruptFrame = errors.New("corrupt chunked frame")
+)
+
+// splitFrame marshals msg and splits it into ChunkFrames of at most size
+// bytes. It returns nil when msg fits in a single frame.
+func splitFrame(msg proto.Message, size int) ([]*bridgepb.ChunkFrame, error) {
+	if size <= 0 || proto.Size(msg) <= size {
+		return nil, nil
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal frame: %w", err)
+	}
+	total := (len(data) + size - 1) / size
+	id := uuid.NewString()
+	chunks := make([]*bridgepb.ChunkFrame, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * size
+		if end > len(data) {
+			end = len(data)
+		}
+		chunks = append(chunks, &bridgepb.ChunkFrame{
+			ChunkId: id,
+			Index:   uint32(i),
+			Total:   uint32(total),
+			Data:    data[i*size : end],
+		})
+	}
+	return chunks, nil
+}
+
+// chunkAssembler collects ChunkFrames until a frame is complete. At most
+// maxPartialFrames frames of maxSize bytes in total are held; a frame left
+// incomplete past timeout or evicted by a newer one is reported as an error,
+// since it is lost. It is owned by a single stream receive loop.
+type chunkAssembler struct {
+	maxSize int
+	timeout time.Duration
+	pending map[string]*partialFrame
+	// size is the byte count of all pending parts.
+	size int
+}
+
+type partialFrame struct {
+	parts    [][]byte
+	received int
+	size     int
+	started  time.Time
+}
+
+func newChunkAssembler(maxSize int, timeout time.Duration) *chunkAssembler {
+	if maxSize <= 0 {
+		maxSize = defaultMaxChunkedSize
+	}
+	if timeout <= 0 {
+		timeout = defaultChunkTimeout
+	}
+	return &chunkAssembler{maxSize: maxSize, timeout: timeout, pending: map[string]*partialFrame{}}
+}
+
+// add stores a chunk and returns the reassembled bytes once all parts arrived.
+func (a *chunkAssembler) add(chunk *bridgepb.ChunkFrame) ([]byte, error) {
+	if chunk == nil || chunk.GetChunkId() == "" || chunk.GetTotal() == 0 || chunk.GetIndex() >= chunk.GetTotal() {
+		return nil, errors.New("invalid chunk frame")
+	}
+	if err := a.expire(); err != nil {
+		return nil, err
+	}
+	p, ok := a.pending[chunk.ChunkId]
+	if !ok {
+		if len(a.pending) >= maxPartialFrames {
+			id := a.oldest()
+			a.drop(id)
+			return nil, fmt.Errorf("frame %s evicted: %d frames in flight", id, maxPartialFrames)
+		}
+		p = &partialFrame{parts: make([][]byte, chunk.Total), started: time.Now()}
+		a.pending[chunk.ChunkId] = p
+	}
+	if int(chunk.Total) != len(p.parts) {
+		a.drop(chunk.ChunkId)
+		return nil, errors.New("chunk total mismatch")
+	}
+	if p.parts[chunk.Index] != nil {
+		return nil, nil
+	}
+	if p.size+len(chunk.Data) > a.maxSize || a.size+len(chunk.Data) > a.maxSize {
+		a.drop(chunk.ChunkId)
+		return nil, ErrChunkedTooLarge
+	}
+	p.size += len(chunk.Data)
+	a.size += len(chunk.Data)
+	p.parts[chunk.Index] = chunk.Data
+	p.received++
+	if p.received < len(p.parts) {
+		return nil, nil
+	}
+	a.drop(chunk.ChunkId)
+	out := make([]byte, 0, p.size)
+	for _, part := range p.parts {
+		out = append(out, part...)
+	}
+	return out, nil
+}
+
+// frame feeds chunk to the assembler and, once the frame is complete,
+// unmarshals it into msg. It reports false while parts are outstanding. An
+// error wraps ErrCorruptFrame: the frame cannot be recovered or nacked since
+// its content is unknown, so callers reset the stream.
+func (a *chunkAssembler) frame(chunk *bridgepb.ChunkFrame, msg proto.Message) (bool, error) {
+	data, err := a.add(chunk)
+	if err != nil {
+		return false, fmt.Errorf("%w %s: %w", ErrCorruptFrame, chunk.GetChunkId(), err)
+	}
+	if data == nil {
+		return false, nil
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return false, fmt.Errorf("%w %s: unmarshal: %w", ErrCorruptFrame, chunk.GetChunkId(), err)
+	}
+	return true, nil
+}
+
+// expire drops frames still incomplete after timeout and reports the first.
+func (a *chunkAssembler) expire() error {
+	cutoff := time.Now().Add(-a.timeout)
+	var err error
+	for id, p := range a.pending {
+		if p.started.Before(cutoff) {
+			a.drop(id)
+			if err == nil {
+				err = fmt.Errorf("frame %s incomplete after %s", id, a.timeout)
+			}
+		}
+	}
+	return err
+}
+
+// oldest returns the id of the frame pending the longest.
+func (a *chunkAssembler) oldest() string {
+	var id string
+	var started time.Time
+	for k, p := range a.pending {
+		if id == "" || p.started.Before(started) {
+			id, started = k, p.started
+		}
+	}
+	return id
+}
+
+func (a *chunkAssembler) drop(id string) {
+	if p, ok := a.pending[id]; ok {
+		a.size -= p.size
+		delete(a.pending, id)
+	}
+}
@@ -0,0 +1,124 @@
+package bridge
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+)
+
+func TestSplitFrameFits(t *testing.T) {
+	chunks, err := splitFrame(codecTestFrame(100), 1024)
+	if err != nil || chunks != nil {
+		t.Fatalf("small frame split into %d chunks, err %v", len(chunks), err)
+	}
+}
+
+func TestChunkReassembly(t *testing.T) {
+	in := codecTestFrame(10_000)
+	chunks, err := splitFrame(in, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) < 10 {
+		t.Fatalf("split into %d chunks", len(chunks))
+	}
+	rand.Shuffle(len(chunks), func(i, j int) { chunks[i], chunks[j] = chunks[j], chunks[i] })
+	// A duplicate part is ignored.
+	chunks = append(chunks[:1], chunks...)
+
+	a := newChunkAssembler(0, 0)
+	out := &bridgepb.StreamRequest{}
+	for i, chunk := range chunks {
+		done, err := a.frame(chunk, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if done != (i == len(chunks)-1) {
+			t.Fatalf("chunk %d of %d: done %t", i+1, len(chunks), done)
+		}
+	}
+	if !proto.Equal(in, out) {
+		t.Fatal("reassembled frame differs")
+	}
+	if len(a.pending) != 0 || a.size != 0 {
+		t.Fatalf("assembler kept %d frames, %d bytes", len(a.pending), a.size)
+	}
+}
+
+func TestChunkCorrupt(t *testing.T) {
+	chunks, err := splitFrame(codecTestFrame(4000), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := proto.Clone(chunks[0]).(*bridgepb.ChunkFrame)
+	corrupt.Data = []byte(strings.Repeat("\xff", len(corrupt.Data)))
+	mismatch := proto.Clone(chunks[1]).(*bridgepb.ChunkFrame)
+	mismatch.Total++
+
+	cases := map[string][]*bridgepb.ChunkFrame{
+		"corrupt data":     append([]*bridgepb.ChunkFrame{corrupt}, chunks[1:]...),
+		"total mismatch":   {chunks[0], mismatch},
+		"index past total": {{ChunkId: "x", Index: 2, Total: 2, Data: []byte("a")}},
+		"empty id":         {{Index: 0, Total: 1, Data: []byte("a")}},
+	}
+	for name, parts := range cases {
+		a := newChunkAssembler(0, 0)
+		var err error
+		for _, chunk := range parts {
+			if _, err = a.frame(chunk, &bridgepb.StreamRequest{}); err != nil {
+				break
+			}
+		}
+		if !errors.Is(err, ErrCorruptFrame) {
+			t.Fatalf("%s: err %v, want ErrCorruptFrame", name, err)
+		}
+	}
+}
+
+func TestChunkLimits(t *testing.T) {
+	chunks, err := splitFrame(codecTestFrame(4000), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A frame larger than maxSize.
+	a := newChunkAssembler(2500, 0)
+	for _, chunk := range chunks {
+		if _, err = a.frame(chunk, &bridgepb.StreamRequest{}); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrChunkedTooLarge) {
+		t.Fatalf("oversized frame: err %v", err)
+	}
+
+	// Partial frames beyond maxPartialFrames evict the oldest.
+	a = newChunkAssembler(0, 0)
+	for i := range maxPartialFrames {
+		part := &bridgepb.ChunkFrame{ChunkId: string(rune('a' + i)), Index: 0, Total: 2, Data: []byte("x")}
+		if _, err := a.frame(part, &bridgepb.StreamRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = a.frame(&bridgepb.ChunkFrame{ChunkId: "z", Index: 0, Total: 2, Data: []byte("x")}, &bridgepb.StreamRequest{})
+	if !errors.Is(err, ErrCorruptFrame) || len(a.pending) != maxPartialFrames-1 {
+		t.Fatalf("frame over the cap: err %v, %d pending", err, len(a.pending))
+	}
+
+	// A frame left incomplete past the timeout.
+	a = newChunkAssembler(0, time.Millisecond)
+	if _, err := a.frame(chunks[0], &bridgepb.StreamRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	_, err = a.frame(chunks[1], &bridgepb.StreamRequest{})
+	if !errors.Is(err, ErrCorruptFrame) || len(a.pending) != 0 || a.size != 0 {
+		t.Fatalf("expired frame: err %v, %d pending, %d bytes", err, len(a.pending), a.size)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,6 +18,7 @@ import (
 
 	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
 	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
 )
 
 var (
@@ -25,9 +28,19 @@ var (
 
 type client struct {
 	opts Options
+	// codec encodes frames when Options.Compression is set; nil sends plain protobuf.
+	codec *frameCodec
 
-	deliverCh   chan *Delivery
-	broadcastCh chan *BroadcastDelivery
+	// ctx is cancelled by Close and bounds subscribers and handler workers.
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	deliverCh          chan *Delivery
+	broadcastCh        chan *BroadcastDelivery
+	controlCh          chan *ControlRequest
+	deliverConsumers   atomic.Int32
+	broadcastConsumers atomic.Int32
+	controlConsumers   atomic.Int32
 
 	conn      *grpc.ClientConn
 	stream    bridgepb.SidecarBridge_StreamClient
@@ -40,6 +53,25 @@ type client struct {
 	stopOnce  sync.Once
 
 	sendMu sync.Mutex
+	// ingress numbers and retains Ingress frames for resumption; guarded by
+	// sendMu, nil when disabled.
+	ingress *ingressBuffer
+
+	// acks queues AckFrames and ControlResultFrames for ackLoop, so settling
+	// a delivery or control never waits on sendMu while publishers hold it
+	// on a full stream.
+	ackMu    sync.Mutex
+	acks     []*bridgepb.StreamRequest
+	ackReady chan struct{}
+
+	// resumeToken and lastSeq identify the last Deliver/Broadcast frame
+	// received, sent in RegisterFrame to resume after a reconnect.
+	resumeMu    sync.Mutex
+	resumeToken string
+	lastSeq     atomic.Uint64
+
+	// reorder is nil unless Options.OrderedDelivery is set.
+	reorder *reorderBuffer
 
 	inflight chan struct{}
 	wg       sync.WaitGroup
@@ -69,14 +101,40 @@ func NewClient(opts Options) (Client, error) {
 	if opts.BridgeVersion == "" {
 		opts.BridgeVersion = envelope.Version
 	}
+	if opts.MaxFrameSize <= 0 {
+		opts.MaxFrameSize = defaultMaxFrameSize
+	}
+	if opts.ReplayBufferSize == 0 {
+		opts.ReplayBufferSize = defaultReplayBufferSize
+	}
+	capFrameSize(&opts)
+	codec, err := newFrameCodec(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Compression == CompressionNone {
+		codec = nil
+	}
 	c := &client{
 		opts:        opts,
+		codec:       codec,
 		deliverCh:   make(chan *Delivery, opts.DeliverBuffer),
 		broadcastCh: make(chan *BroadcastDelivery, opts.BroadcastBuffer),
+		controlCh:   make(chan *ControlRequest, controlBuffer),
+		ackReady:    make(chan struct{}, 1),
+	}
+	c.ctx, c.ctxCancel = context.WithCancel(context.Background())
+	if opts.OrderedDelivery {
+		c.reorder = newReorderBuffer(c.ctx, opts.ReorderTimeout, opts.OnSequenceGap, func(ctx context.Context, d *Delivery) bool {
+			return enqueue(ctx, c.deliverCh, &c.deliverConsumers, d)
+		})
 	}
 	if opts.EnableBackpressure && opts.MaxInFlightDeliver > 0 {
 		c.inflight = make(chan struct{}, opts.MaxInFlightDeliver)
 	}
+	if opts.ReplayBufferSize > 0 {
+		c.ingress = newIngressBuffer(opts.ReplayBufferSize)
+	}
 	return c, nil
 }
 
@@ -86,8 +144,9 @@ func (c *client) Start(ctx context.Context) error {
 	c.startOnce.Do(func() {
 		runCtx, cancel := context.WithCancel(ctx)
 		c.runCancel = cancel
-		c.wg.Add(1)
+		c.wg.Add(2)
 		go c.run(runCtx)
+		go c.ackLoop()
 	})
 	return err
 }
@@ -138,7 +197,18 @@ func (c *client) connect(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	c.cancel = cancel
 	var dialOpts []grpc.DialOption
-	if c.opts.Insecure {
+	target := c.opts.Address
+	scheme, name := splitAddress(c.opts.Address)
+	if scheme == SchemeInProcess {
+		// In-process connections never leave the process, so TLS is skipped.
+		target = "passthrough:///" + name
+		dialOpts = append(dialOpts,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return dialInProcess(ctx, name)
+			}),
+		)
+	} else if c.opts.Insecure {
 		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	} else {
 		tlsConf := &tls.Config{}
@@ -151,12 +221,16 @@ func (c *client) connect(ctx context.Context) error {
 		}
 		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConf)))
 	}
+	if c.codec != nil {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.CallContentSubtype(codecName)))
+	}
+	dialOpts = append(dialOpts, c.opts.dialOptions()...)
 	dialTimeout := c.opts.DialTimeout
 	if dialTimeout <= 0 {
 		dialTimeout = 5 * time.Second
 	}
 	dctx, cancelDial := context.WithTimeout(ctx, dialTimeout)
-	conn, dialErr := grpc.DialContext(dctx, c.opts.Address, dialOpts...)
+	conn, dialErr := grpc.DialContext(dctx, target, dialOpts...)
 	cancelDial()
 	if dialErr != nil {
 		return fmt.Errorf("dial bridge: %w", dialErr)
@@ -166,81 +240,193 @@ func (c *client) connect(ctx context.Context) error {
 	streamCtx := ctx
 	if len(c.opts.Metadata) > 0 {
 		md := metadata.New(c.opts.Metadata)
-		streamCtx = metadata.NewOutgoingContext(dctx, md)
+		streamCtx = metadata.NewOutgoingContext(ctx, md)
 	}
 	stream, streamErr := client.Stream(streamCtx)
 	if streamErr != nil {
 		return fmt.Errorf("create stream: %w", streamErr)
 	}
-	c.stream = stream
+	c.resumeMu.Lock()
 	reg := &bridgepb.RegisterFrame{
 		NodeId:            c.opts.NodeID,
 		Namespace:         c.opts.Namespace,
 		SupportedVersions: c.opts.SupportedVersions,
 		BridgeVersion:     c.opts.BridgeVersion,
+		ResumeToken:       c.resumeToken,
+		LastSeq:           c.lastSeq.Load(),
 	}
+	c.resumeMu.Unlock()
 	req := &bridgepb.StreamRequest{Payload: &bridgepb.StreamRequest_Register{Register: reg}}
-	if sendErr := stream.Send(req); sendErr != nil {
+	c.opts.Recorder.recordRequest(RecordSideClient, c.opts.NodeID, "", req)
+	// stream is not published in c.stream yet, so nothing else writes to it.
+	if sendErr := c.codec.send(stream, req); sendErr != nil {
 		return fmt.Errorf("send register: %w", sendErr)
 	}
+	var acked uint64
+	resend := false
+	if reg.ResumeToken != "" {
+		acked, resend = c.ingressAcked(stream, reg.ResumeToken, dialTimeout)
+	}
+	// Resend missed Ingress frames before publishing the stream, so none is
+	// overtaken by a new one the worker would then drop as a duplicate.
+	c.sendMu.Lock()
+	if resend {
+		for _, req := range c.ingress.since(acked) {
+			if err := c.write(stream, req); err != nil {
+				c.sendMu.Unlock()
+				return fmt.Errorf("resend ingress: %w", err)
+			}
+		}
+	}
+	c.stream = stream
+	c.sendMu.Unlock()
 	c.started.Store(true)
 	c.recvErr = make(chan error, 1)
 	c.wg.Add(1)
 	go c.heartbeatLoop(ctx)
-	go c.consume(ctx)
+	go c.consume(ctx, stream)
 	return nil
 }
 
-func (c *client) consume(ctx context.Context) {
+// ingressAcked waits for the stream headers and returns the last Ingress
+// seq the worker received, with ok false unless it accepted token. A worker
+// that does not answer with headers within timeout gets nothing resent.
+func (c *client) ingressAcked(stream bridgepb.SidecarBridge_StreamClient, token string, timeout time.Duration) (last uint64, ok bool) {
+	if c.ingress == nil {
+		return 0, false
+	}
+	header := make(chan metadata.MD, 1)
+	go func() {
+		md, _ := stream.Header()
+		header <- md
+	}()
+	var md metadata.MD
+	select {
+	case md = <-header:
+	case <-time.After(timeout):
+		return 0, false
+	}
+	if first(md.Get(resumeTokenHeader)) != token {
+		return 0, false
+	}
+	last, err := strconv.ParseUint(first(md.Get(ingressSeqHeader)), 10, 64)
+	return last, err == nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// consume reads stream, not c.stream: cleanup clears c.stream while Close
+// races with the last Recv.
+func (c *client) consume(ctx context.Context, stream bridgepb.SidecarBridge_StreamClient) {
+	chunks := newChunkAssembler(c.opts.MaxChunkedSize, c.opts.ChunkTimeout)
+	if md, err := stream.Header(); err == nil {
+		if c.setResumeToken(md.Get(resumeTokenHeader)) && c.reorder != nil {
+			c.reorder.reset(ctx)
+		}
+	}
 	for {
-		resp, err := c.stream.Recv()
-		if err != nil {
+		resp := &bridgepb.StreamResponse{}
+		if err := c.codec.recv(stream, resp); err != nil {
 			if c.recvErr != nil {
 				c.recvErr <- err
 			}
 			return
 		}
-		payload := resp.GetPayload()
-		switch payload := payload.(type) {
-		case *bridgepb.StreamResponse_Deliver:
-			if payload.Deliver != nil && payload.Deliver.Envelope != nil {
-				env := payload.Deliver.Envelope
-				messageID := ""
-				if env.GetMessage() != nil {
-					messageID = env.GetMessage().GetRequestId()
+		if chunk := resp.GetChunk(); chunk != nil {
+			resp = &bridgepb.StreamResponse{}
+			done, err := chunks.frame(chunk, resp)
+			if err != nil {
+				// Reconnecting resumes after lastSeq, so the worker resends the lost frame.
+				logger.WithTrace(ctx).WithError(err).Warn("bridge frame lost, resetting stream")
+				if c.recvErr != nil {
+					c.recvErr <- err
 				}
-				delivery := newDelivery(env, func(ctx context.Context) error {
-					if messageID == "" {
-						return nil
-					}
-					return c.sendAck(ctx, messageID, "")
-				})
-				select {
-				case c.deliverCh <- delivery:
-				case <-ctx.Done():
-					return
+				return
+			}
+			if !done {
+				continue
+			}
+		}
+		c.opts.Recorder.recordResponse(RecordSideClient, c.opts.NodeID, "", resp)
+		seq := resp.GetSeq()
+		if seq > 0 && seq <= c.lastSeq.Load() {
+			// Already received before a resume.
+			continue
+		}
+		if !c.dispatch(ctx, resp) {
+			return
+		}
+		if seq > 0 {
+			c.lastSeq.Store(seq)
+		}
+	}
+}
+
+// setResumeToken records the token of the current stream and reports
+// whether it changed: sequence numbers (frame seq and nfc_sequence) of a
+// different worker instance start over.
+func (c *client) setResumeToken(values []string) bool {
+	token := first(values)
+	c.resumeMu.Lock()
+	defer c.resumeMu.Unlock()
+	if token == c.resumeToken {
+		return false
+	}
+	c.resumeToken = token
+	c.lastSeq.Store(0)
+	return true
+}
+
+// dispatch routes a server frame; it returns false once ctx is done.
+func (c *client) dispatch(ctx context.Context, resp *bridgepb.StreamResponse) bool {
+	switch payload := resp.GetPayload().(type) {
+	case *bridgepb.StreamResponse_Deliver:
+		if payload.Deliver != nil && payload.Deliver.Envelope != nil {
+			env := payload.Deliver.Envelope
+			messageID := ""
+			if env.GetMessage() != nil {
+				messageID = env.GetMessage().GetRequestId()
+			}
+			delivery := newDelivery(env, func(ctx context.Context, status, reason string) error {
+				if messageID == "" {
+					return nil
 				}
+				return c.sendAck(ctx, &bridgepb.AckFrame{MessageId: messageID, Status: status, Reason: reason})
+			})
+			if c.reorder != nil {
+				return c.reorder.push(ctx, delivery)
 			}
-		case *bridgepb.StreamResponse_Broadcast:
-			if payload.Broadcast != nil && payload.Broadcast.Envelope != nil {
-				env := payload.Broadcast.Envelope
-				broadcastID := payload.Broadcast.GetBroadcastId()
-				delivery := newBroadcastDelivery(env, broadcastID, func(ctx context.Context) error {
-					if broadcastID == "" {
-						return nil
-					}
-					return c.sendAck(ctx, "", broadcastID)
-				})
-				select {
-				case c.broadcastCh <- delivery:
-				case <-ctx.Done():
-					return
+			if !enqueue(ctx, c.deliverCh, &c.deliverConsumers, delivery) {
+				return false
+			}
+		}
+	case *bridgepb.StreamResponse_Broadcast:
+		if payload.Broadcast != nil && payload.Broadcast.Envelope != nil {
+			env := payload.Broadcast.Envelope
+			broadcastID := payload.Broadcast.GetBroadcastId()
+			delivery := newBroadcastDelivery(env, broadcastID, func(ctx context.Context, status, reason string) error {
+				if broadcastID == "" {
+					return nil
 				}
+				return c.sendAck(ctx, &bridgepb.AckFrame{BroadcastId: broadcastID, Status: status, Reason: reason})
+			})
+			if !enqueue(ctx, c.broadcastCh, &c.broadcastConsumers, delivery) {
+				return false
 			}
-		case *bridgepb.StreamResponse_Heartbeat:
-			// no-op
 		}
+	case *bridgepb.StreamResponse_Control:
+		if payload.Control != nil {
+			return c.dispatchControl(ctx, payload.Control)
+		}
+	case *bridgepb.StreamResponse_Heartbeat:
+		// no-op
 	}
+	return true
 }
 
 func (c *client) PublishIngress(ctx context.Context, env envelope.TransportEnvelope) error {
@@ -257,21 +443,7 @@ func (c *client) PublishIngress(ctx context.Context, env envelope.TransportEnvel
 			Ingress: &bridgepb.IngressFrame{Envelope: &env},
 		},
 	}
-	c.sendMu.Lock()
-	err := c.stream.Send(req)
-	c.sendMu.Unlock()
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func (c *client) SubscribeDeliver(context.Context) (<-chan *Delivery, error) {
-	return c.deliverCh, nil
-}
-
-func (c *client) SubscribeBroadcast(context.Context) (<-chan *BroadcastDelivery, error) {
-	return c.broadcastCh, nil
+	return c.send(req)
 }
 
 func (c *client) Drain(ctx context.Context) error {
@@ -298,15 +470,17 @@ func (c *client) Close() error {
 		if c.cancel != nil {
 			c.cancel()
 		}
+		// The stream context is cancelled, so senders holding sendMu return.
+		c.sendMu.Lock()
 		if c.stream != nil {
 			err = c.stream.CloseSend()
 		}
+		c.sendMu.Unlock()
 		if c.conn != nil {
 			_ = c.conn.Close()
 		}
-		close(c.deliverCh)
-		close(c.broadcastCh)
 		c.closed.Store(true)
+		c.ctxCancel()
 	})
 	return err
 }
@@ -316,10 +490,12 @@ func (c *client) cleanup() {
 		c.cancel()
 		c.cancel = nil
 	}
+	c.sendMu.Lock()
 	if c.stream != nil {
 		_ = c.stream.CloseSend()
 		c.stream = nil
 	}
+	c.sendMu.Unlock()
 	if c.conn != nil {
 		_ = c.conn.Close()
 		c.conn = nil
@@ -349,15 +525,19 @@ func (c *client) sendHeartbeat(ctx context.Context) {
 		return
 	}
 	req := &bridgepb.StreamRequest{Payload: &bridgepb.StreamRequest_Heartbeat{Heartbeat: &bridgepb.HeartbeatFrame{Nonce: fmt.Sprintf("%d", time.Now().UnixNano())}}}
-	c.sendMu.Lock()
-	_ = c.stream.Send(req)
-	c.sendMu.Unlock()
+	_ = c.send(req)
 }
 
-func (c *client) sendAck(ctx context.Context, messageID, broadcastID string) error {
-	if messageID == "" && broadcastID == "" {
+func (c *client) sendAck(ctx context.Context, ack *bridgepb.AckFrame) error {
+	if ack.GetMessageId() == "" && ack.GetBroadcastId() == "" {
 		return nil
 	}
+	return c.queueAck(ctx, &bridgepb.StreamRequest{Payload: &bridgepb.StreamRequest_Ack{Ack: ack}})
+}
+
+// queueAck hands an Ack or ControlResult frame to ackLoop without waiting
+// for the stream.
+func (c *client) queueAck(ctx context.Context, req *bridgepb.StreamRequest) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -366,17 +546,78 @@ func (c *client) sendAck(ctx context.Context, messageID, broadcastID string) err
 		return ctx.Err()
 	default:
 	}
-	req := &bridgepb.StreamRequest{
-		Payload: &bridgepb.StreamRequest_Ack{
-			Ack: &bridgepb.AckFrame{MessageId: messageID, BroadcastId: broadcastID},
-		},
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	c.ackMu.Lock()
+	c.acks = append(c.acks, req)
+	c.ackMu.Unlock()
+	select {
+	case c.ackReady <- struct{}{}:
+	default:
 	}
+	return nil
+}
+
+// ackLoop writes queued acks and control results until the client is
+// closed. Write errors are only logged: the frame is lost with the stream,
+// and the worker redelivers or times out.
+func (c *client) ackLoop() {
+	defer c.wg.Done()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-c.ackReady:
+		}
+		c.ackMu.Lock()
+		acks := c.acks
+		c.acks = nil
+		c.ackMu.Unlock()
+		for _, req := range acks {
+			if err := c.send(req); err != nil {
+				entry := logger.WithError(err)
+				if ack := req.GetAck(); ack != nil {
+					entry = entry.WithField("message_id", ack.GetMessageId()).WithField("broadcast_id", ack.GetBroadcastId())
+				} else {
+					entry = entry.WithField("control_id", req.GetControlResult().GetControlId())
+				}
+				entry.Warn("bridge ack not sent")
+			}
+		}
+	}
+}
+
+// send writes a frame, splitting it into ChunkFrames when it exceeds MaxFrameSize.
+// Ingress frames are numbered and retained for resumption.
+func (c *client) send(req *bridgepb.StreamRequest) error {
 	c.sendMu.Lock()
 	defer c.sendMu.Unlock()
 	if c.stream == nil {
 		return errors.New("stream not ready")
 	}
-	return c.stream.Send(req)
+	if c.ingress != nil && req.GetIngress() != nil {
+		c.ingress.record(req)
+	}
+	return c.write(c.stream, req)
+}
+
+// write sends req on stream; the caller holds sendMu.
+func (c *client) write(stream bridgepb.SidecarBridge_StreamClient, req *bridgepb.StreamRequest) error {
+	c.opts.Recorder.recordRequest(RecordSideClient, c.opts.NodeID, "", req)
+	chunks, err := splitFrame(req, c.opts.MaxFrameSize)
+	if err != nil {
+		return err
+	}
+	if chunks == nil {
+		return c.codec.send(stream, req)
+	}
+	for _, chunk := range chunks {
+		if err := c.codec.send(stream, &bridgepb.StreamRequest{Payload: &bridgepb.StreamRequest_Chunk{Chunk: chunk}}); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (c *client) acquireSlot(ctx context.Context) error {
@@ -0,0 +1,219 @@
+package bridge
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Supported values for Options.Compression.
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+const (
+	defaultCompressionThreshold = 1024
+	// defaultMaxRecvMsgSize matches gRPC's default receive limit.
+	defaultMaxRecvMsgSize = 4 << 20
+)
+
+// codecName is the gRPC content-subtype ("application/grpc+bridge") of
+// streams whose frames are encoded by a frameCodec. Clients with
+// Compression set use it; the server answers each stream in kind.
+const codecName = "bridge"
+
+// Every frame on a "bridge" stream starts with a marker byte naming its
+// encoding, so frames below the threshold (heartbeats, acks) travel as-is
+// and either side decodes whatever the other chose.
+const (
+	frameStored byte = 0
+	frameGzip   byte = 1
+	frameZstd   byte = 2
+)
+
+func init() {
+	encoding.RegisterCodec(rawCodec{})
+}
+
+// rawFrame carries frame bytes already encoded by a frameCodec.
+type rawFrame struct {
+	data []byte
+}
+
+// rawCodec passes rawFrames through gRPC unchanged. gRPC resolves codecs
+// and compressors by name, process-wide; encoding in frameCodec instead
+// keeps the algorithm, threshold and limits per client and server.
+type rawCodec struct{}
+
+func (rawCodec) Name() string {
+	return codecName
+}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	f, ok := v.(*rawFrame)
+	if !ok {
+		return nil, fmt.Errorf("bridge codec: unexpected message %T", v)
+	}
+	return f.data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	f, ok := v.(*rawFrame)
+	if !ok {
+		return fmt.Errorf("bridge codec: unexpected message %T", v)
+	}
+	f.data = data
+	return nil
+}
+
+// frameCodec encodes the frames of one client or server.
+type frameCodec struct {
+	// marker is the encoding used to send; frameStored never compresses.
+	marker    byte
+	threshold int
+	maxRecv   int
+	zstd      *zstd.Decoder
+}
+
+// newFrameCodec validates opts.Compression and builds the codec.
+func newFrameCodec(opts Options) (*frameCodec, error) {
+	c := &frameCodec{threshold: opts.CompressionThreshold, maxRecv: opts.MaxRecvMsgSize}
+	switch opts.Compression {
+	case CompressionNone:
+		c.marker = frameStored
+	case CompressionGzip:
+		c.marker = frameGzip
+	case CompressionZstd:
+		c.marker = frameZstd
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", opts.Compression)
+	}
+	if c.threshold <= 0 {
+		c.threshold = defaultCompressionThreshold
+	}
+	if c.maxRecv <= 0 {
+		c.maxRecv = defaultMaxRecvMsgSize
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(uint64(c.maxRecv)))
+	if err != nil {
+		return nil, err
+	}
+	c.zstd = dec
+	return c, nil
+}
+
+// usesCodec reports whether the incoming stream of ctx was opened with the
+// bridge content-subtype.
+func usesCodec(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, ct := range md.Get("content-type") {
+		if strings.HasSuffix(ct, "+"+codecName) {
+			return true
+		}
+	}
+	return false
+}
+
+// msgStream is the part of grpc.ClientStream and grpc.ServerStream frames
+// are written with.
+type msgStream interface {
+	SendMsg(m any) error
+	RecvMsg(m any) error
+}
+
+// send writes m on stream; a nil codec leaves encoding to gRPC (protobuf).
+func (c *frameCodec) send(stream msgStream, m proto.Message) error {
+	if c == nil {
+		return stream.SendMsg(m)
+	}
+	data, err := c.encode(m)
+	if err != nil {
+		return err
+	}
+	return stream.SendMsg(&rawFrame{data: data})
+}
+
+// recv reads the next frame of stream into m.
+func (c *frameCodec) recv(stream msgStream, m proto.Message) error {
+	if c == nil {
+		return stream.RecvMsg(m)
+	}
+	f := &rawFrame{}
+	if err := stream.RecvMsg(f); err != nil {
+		return err
+	}
+	return c.decode(f.data, m)
+}
+
+func (c *frameCodec) encode(m proto.Message) ([]byte, error) {
+	out, err := proto.MarshalOptions{}.MarshalAppend([]byte{frameStored}, m)
+	if err != nil || c.marker == frameStored || len(out)-1 < c.threshold {
+		return out, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(out) / 2)
+	buf.WriteByte(c.marker)
+	switch c.marker {
+	case frameGzip:
+		zw := gzipWriterPool.Get().(*gzip.Writer)
+		defer gzipWriterPool.Put(zw)
+		zw.Reset(&buf)
+		if _, err := zw.Write(out[1:]); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	case frameZstd:
+		return zstdEncoder.EncodeAll(out[1:], buf.Bytes()), nil
+	}
+	return buf.Bytes(), nil
+}
+
+// decode unmarshals a frame into m; decompressed frames are limited to
+// maxRecv bytes like uncompressed ones are by gRPC.
+func (c *frameCodec) decode(data []byte, m proto.Message) error {
+	if len(data) == 0 {
+		return errors.New("empty bridge frame")
+	}
+	body := data[1:]
+	switch data[0] {
+	case frameStored:
+	case frameGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if body, err = io.ReadAll(io.LimitReader(zr, int64(c.maxRecv)+1)); err != nil {
+			return err
+		}
+		if len(body) > c.maxRecv {
+			return fmt.Errorf("decompressed frame larger than %d bytes", c.maxRecv)
+		}
+	case frameZstd:
+		var err error
+		if body, err = c.zstd.DecodeAll(body, nil); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown bridge frame marker %d", data[0])
+	}
+	return proto.Unmarshal(body, m)
+}
+
+var gzipWriterPool = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+
+// zstdEncoder is stateless through EncodeAll, which is safe for concurrent use.
+var zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
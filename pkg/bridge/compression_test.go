@@ -0,0 +1,114 @@
+package bridge
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+)
+
+func codecTestFrame(size int) *bridgepb.StreamRequest {
+	return &bridgepb.StreamRequest{Payload: &bridgepb.StreamRequest_Ingress{Ingress: &bridgepb.IngressFrame{Envelope: &bridgepb.TransportEnvelope{
+		ConnectionId: "conn",
+		Message: &bridgepb.Message{
+			Action:  "codec.test",
+			Payload: &bridgepb.Payload{Text: &bridgepb.TextPayload{Content: strings.Repeat("x", size)}},
+		},
+	}}}}
+}
+
+func TestFrameCodecRoundTrip(t *testing.T) {
+	cases := []struct {
+		compression string
+		size        int
+		marker      byte
+	}{
+		{CompressionNone, 4096, frameStored},
+		{CompressionGzip, 16, frameStored},
+		{CompressionGzip, 4096, frameGzip},
+		{CompressionZstd, 16, frameStored},
+		{CompressionZstd, 4096, frameZstd},
+	}
+	// Any receiver decodes any marker, whatever its own Compression.
+	receivers := []string{CompressionNone, CompressionGzip, CompressionZstd}
+	for _, tc := range cases {
+		sender, err := newFrameCodec(Options{Compression: tc.compression})
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := codecTestFrame(tc.size)
+		data, err := sender.encode(in)
+		if err != nil {
+			t.Fatalf("%q/%d: encode: %v", tc.compression, tc.size, err)
+		}
+		if data[0] != tc.marker {
+			t.Fatalf("%q/%d: marker %d, want %d", tc.compression, tc.size, data[0], tc.marker)
+		}
+		for _, r := range receivers {
+			receiver, err := newFrameCodec(Options{Compression: r})
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := &bridgepb.StreamRequest{}
+			if err := receiver.decode(data, out); err != nil {
+				t.Fatalf("%q/%d decoded by %q: %v", tc.compression, tc.size, r, err)
+			}
+			if !proto.Equal(in, out) {
+				t.Fatalf("%q/%d decoded by %q: frame changed", tc.compression, tc.size, r)
+			}
+		}
+	}
+}
+
+func TestFrameCodecDecompressedLimit(t *testing.T) {
+	const limit = 64 << 10
+	for _, compression := range []string{CompressionGzip, CompressionZstd} {
+		sender, err := newFrameCodec(Options{Compression: compression})
+		if err != nil {
+			t.Fatal(err)
+		}
+		receiver, err := newFrameCodec(Options{MaxRecvMsgSize: limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		small, err := sender.encode(codecTestFrame(limit / 2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := receiver.decode(small, &bridgepb.StreamRequest{}); err != nil {
+			t.Fatalf("%s: frame under the limit: %v", compression, err)
+		}
+		bomb, err := sender.encode(codecTestFrame(limit * 16))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bomb) > limit {
+			t.Fatalf("%s: compressed frame is %d bytes, test needs it under %d", compression, len(bomb), limit)
+		}
+		if err := receiver.decode(bomb, &bridgepb.StreamRequest{}); err == nil {
+			t.Fatalf("%s: frame decompressing past MaxRecvMsgSize was accepted", compression)
+		}
+	}
+}
+
+func TestFrameCodecRejectsBadFrames(t *testing.T) {
+	c, err := newFrameCodec(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"empty":          nil,
+		"unknown marker": {9, 1, 2, 3},
+		"corrupt gzip":   {frameGzip, 1, 2, 3},
+		"corrupt zstd":   {frameZstd, 1, 2, 3},
+	} {
+		if err := c.decode(data, &bridgepb.StreamRequest{}); err == nil {
+			t.Fatalf("%s: decoded", name)
+		}
+	}
+	if _, err := newFrameCodec(Options{Compression: "lz4"}); err == nil {
+		t.Fatal("unsupported compression accepted")
+	}
+}
@@ -0,0 +1,61 @@
+package bridge
+
+import (
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/config"
+)
+
+// OptionsFromClientConfig maps a sidecar's bridge client config onto Options.
+// Namespace, NodeID and TLS files are not part of the config and left empty.
+func OptionsFromClientConfig(cfg config.BridgeClientConfig) Options {
+	return Options{
+		Address:                      cfg.Address,
+		Insecure:                     cfg.Insecure,
+		Metadata:                     cfg.Headers,
+		DialTimeout:                  seconds(cfg.DialTimeoutSeconds),
+		HeartbeatInterval:            seconds(cfg.HeartbeatIntervalSeconds),
+		ReconnectBackoff:             seconds(cfg.ReconnectBaseSeconds),
+		MaxReconnectBackoff:          seconds(cfg.ReconnectMaxSeconds),
+		EnableBackpressure:           cfg.EnableBackpressure,
+		MaxInFlightDeliver:           cfg.MaxInFlightDeliver,
+		Compression:                  cfg.Compression,
+		CompressionThreshold:         cfg.CompressionMinBytes,
+		MaxSendMsgSize:               cfg.MaxSendMsgBytes,
+		MaxRecvMsgSize:               cfg.MaxRecvMsgBytes,
+		KeepaliveTime:                seconds(cfg.KeepaliveTimeSeconds),
+		KeepaliveTimeout:             seconds(cfg.KeepaliveTimeoutSeconds),
+		KeepalivePermitWithoutStream: cfg.KeepaliveWithoutStream,
+	}
+}
+
+// OptionsFromServerConfig maps a worker's bridge server config onto Options.
+// The server is plaintext (Insecure) unless a certificate is configured.
+func OptionsFromServerConfig(cfg config.BridgeServerConfig) Options {
+	return Options{
+		Address:                      cfg.ListenAddr,
+		Namespace:                    cfg.Namespace,
+		TLSCertFile:                  cfg.TLSCertFile,
+		TLSKeyFile:                   cfg.TLSKeyFile,
+		Insecure:                     cfg.TLSCertFile == "",
+		DeliverBuffer:                cfg.DeliverBuffer,
+		HeartbeatInterval:            seconds(cfg.HeartbeatIntervalSeconds),
+		MaxInFlightDeliver:           cfg.MaxInFlightDeliver,
+		Compression:                  cfg.Compression,
+		CompressionThreshold:         cfg.CompressionMinBytes,
+		MaxSendMsgSize:               cfg.MaxSendMsgBytes,
+		MaxRecvMsgSize:               cfg.MaxRecvMsgBytes,
+		KeepaliveTime:                seconds(cfg.KeepaliveTimeSeconds),
+		KeepaliveTimeout:             seconds(cfg.KeepaliveTimeoutSeconds),
+		KeepalivePermitWithoutStream: cfg.KeepaliveWithoutStream,
+		KeepaliveMinTime:             seconds(cfg.KeepaliveMinTimeSeconds),
+		HealthCheckInterval:          seconds(cfg.HealthCheckIntervalSeconds),
+		EnableReflection:             cfg.EnableReflection,
+		ReplayBufferSize:             cfg.ReplayBufferSize,
+		ReplayBufferTTL:              seconds(cfg.ReplayBufferTTLSeconds),
+	}
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,94 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+)
+
+// ConnectionEventType describes a client connection lifecycle change.
+type ConnectionEventType = bridgepb.ConnectionEventType
+
+// Connection lifecycle event types.
+const (
+	ConnectionOpened      = bridgepb.ConnectionEventType_CONNECTION_EVENT_TYPE_OPENED
+	ConnectionClosed      = bridgepb.ConnectionEventType_CONNECTION_EVENT_TYPE_CLOSED
+	ConnectionAuthChanged = bridgepb.ConnectionEventType_CONNECTION_EVENT_TYPE_AUTH_CHANGED
+)
+
+// AttrPreviousUserID is the ConnectionEvent attribute carrying the user id a
+// connection was bound to before a ConnectionAuthChanged event.
+const AttrPreviousUserID = "previous_user_id"
+
+// ConnectionEvent reports a client connection opening, closing or changing
+// identity on a sidecar.
+type ConnectionEvent struct {
+	Type         ConnectionEventType
+	ConnectionID string
+	UserID       int64
+	Reason       string
+	Attributes   map[string]string
+	OccurredAt   time.Time
+}
+
+// ConnectionEventHandler is an optional Handler extension that receives
+// connection lifecycle events. Handlers without it ignore the events.
+type ConnectionEventHandler interface {
+	OnConnectionEvent(ctx context.Context, session Session, event ConnectionEvent) error
+}
+
+func (e ConnectionEvent) frame() *bridgepb.ConnectionEventFrame {
+	occurred := e.OccurredAt
+	if occurred.IsZero() {
+		occurred = time.Now()
+	}
+	return &bridgepb.ConnectionEventFrame{
+		Type:         e.Type,
+		ConnectionId: e.ConnectionID,
+		UserId:       e.UserID,
+		Reason:       e.Reason,
+		Attributes:   e.Attributes,
+		OccurredAt:   timestamppb.New(occurred),
+	}
+}
+
+func connectionEventFromFrame(f *bridgepb.ConnectionEventFrame) ConnectionEvent {
+	event := ConnectionEvent{
+		Type:         f.GetType(),
+		ConnectionID: f.GetConnectionId(),
+		UserID:       f.GetUserId(),
+		Reason:       f.GetReason(),
+		Attributes:   f.GetAttributes(),
+	}
+	if f.GetOccurredAt() != nil {
+		event.OccurredAt = f.GetOccurredAt().AsTime()
+	}
+	return event
+}
+
+// PublishConnectionEvent notifies the worker of a connection lifecycle change.
+func (c *client) PublishConnectionEvent(ctx context.Context, event ConnectionEvent) error {
+	if !c.started.Load() {
+		return ErrNotStarted
+	}
+	if event.ConnectionID == "" {
+		return errors.New("connection id is required")
+	}
+	if event.Type == bridgepb.ConnectionEventType_CONNECTION_EVENT_TYPE_UNSPECIFIED {
+		return errors.New("connection event type is required")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if event.Type == ConnectionClosed && c.reorder != nil {
+		c.reorder.forget(event.ConnectionID)
+	}
+	req := &bridgepb.StreamRequest{
+		Payload: &bridgepb.StreamRequest_ConnectionEvent{ConnectionEvent: event.frame()},
+	}
+	return c.send(req)
+}
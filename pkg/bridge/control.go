@@ -0,0 +1,218 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+)
+
+const (
+	defaultControlTimeout = 10 * time.Second
+	controlBuffer         = 64
+)
+
+// ErrSessionClosed indicates the session ended before an operation completed.
+var ErrSessionClosed = errors.New("bridge session closed")
+
+// ControlCommand selects the action a sidecar applies to client connections.
+type ControlCommand = bridgepb.ControlCommand
+
+// Supported control commands.
+const (
+	// ControlKick disconnects Control.ConnectionIDs.
+	ControlKick = bridgepb.ControlCommand_CONTROL_COMMAND_KICK
+	// ControlCloseUser closes every connection of Control.UserIDs.
+	ControlCloseUser = bridgepb.ControlCommand_CONTROL_COMMAND_CLOSE_USER
+	// ControlThrottle rate-limits the targeted connections; see Control.Params.
+	ControlThrottle = bridgepb.ControlCommand_CONTROL_COMMAND_THROTTLE
+)
+
+// ControlThrottle params. Other keys are rejected by the sidecar.
+const (
+	// ThrottleParamRate is the messages per second allowed (required, "0" lifts the throttle).
+	ThrottleParamRate = "rate_per_sec"
+	// ThrottleParamBurst is the bucket size (optional, default the rate rounded up).
+	ThrottleParamBurst = "burst"
+	// ThrottleParamDuration lifts the throttle after that many milliseconds (optional, default never).
+	ThrottleParamDuration = "duration_ms"
+)
+
+// Control is a worker-to-sidecar command.
+type Control struct {
+	ID            string
+	Command       ControlCommand
+	ConnectionIDs []string
+	UserIDs       []int64
+	Reason        string
+	Params        map[string]string
+}
+
+// ControlResult is the sidecar's answer to a Control.
+type ControlResult struct {
+	ControlID string
+	OK        bool
+	Affected  int
+	Error     string
+}
+
+func (c Control) frame() *bridgepb.ControlFrame {
+	return &bridgepb.ControlFrame{
+		ControlId:     c.ID,
+		Command:       c.Command,
+		ConnectionIds: c.ConnectionIDs,
+		UserIds:       c.UserIDs,
+		Reason:        c.Reason,
+		Params:        c.Params,
+	}
+}
+
+func controlFromFrame(f *bridgepb.ControlFrame) Control {
+	return Control{
+		ID:            f.GetControlId(),
+		Command:       f.GetCommand(),
+		ConnectionIDs: f.GetConnectionIds(),
+		UserIDs:       f.GetUserIds(),
+		Reason:        f.GetReason(),
+		Params:        f.GetParams(),
+	}
+}
+
+// SendControl sends ctl to the sidecar and waits for its result, bounded by
+// ctx and Options.ControlTimeout. An empty ctl.ID is filled with a uuid.
+func (s *session) SendControl(ctx context.Context, ctl Control) (ControlResult, error) {
+	if ctl.ID == "" {
+		ctl.ID = uuid.NewString()
+	}
+	if ctl.Command == bridgepb.ControlCommand_CONTROL_COMMAND_UNSPECIFIED {
+		return ControlResult{}, errors.New("control command is required")
+	}
+	wait := make(chan ControlResult, 1)
+	s.controlMu.Lock()
+	s.controls[ctl.ID] = wait
+	s.controlMu.Unlock()
+	defer func() {
+		s.controlMu.Lock()
+		delete(s.controls, ctl.ID)
+		s.controlMu.Unlock()
+	}()
+
+	resp := &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Control{Control: ctl.frame()}}
+	if err := s.send(ctx, resp); err != nil {
+		return ControlResult{}, err
+	}
+	timer := time.NewTimer(s.controlTimeout)
+	defer timer.Stop()
+	select {
+	case res := <-wait:
+		return res, nil
+	case <-ctx.Done():
+		return ControlResult{}, ctx.Err()
+	case <-s.ctx.Done():
+		return ControlResult{}, ErrSessionClosed
+	case <-timer.C:
+		return ControlResult{}, context.DeadlineExceeded
+	}
+}
+
+// resolveControl hands a ControlResultFrame to the waiting SendControl call.
+func (s *session) resolveControl(f *bridgepb.ControlResultFrame) {
+	s.controlMu.Lock()
+	wait := s.controls[f.GetControlId()]
+	s.controlMu.Unlock()
+	if wait == nil {
+		return
+	}
+	select {
+	case wait <- ControlResult{ControlID: f.GetControlId(), OK: f.GetOk(), Affected: int(f.GetAffected()), Error: f.GetError()}:
+	default:
+	}
+}
+
+// ControlRequest is a Control received by the client that expects a reply.
+type ControlRequest struct {
+	Control Control
+
+	replyFn func(ctx context.Context, res *bridgepb.ControlResultFrame) error
+
+	once    sync.Once
+	err     error
+	settled atomic.Bool
+}
+
+// ControlFunc applies a Control and returns the number of affected connections.
+type ControlFunc func(ctx context.Context, ctl Control) (affected int, err error)
+
+// Reply reports the outcome of the control to the worker. Only the first
+// reply is sent; like Delivery.Ack it is queued and never waits on the stream.
+func (r *ControlRequest) Reply(ctx context.Context, affected int, err error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	r.once.Do(func() {
+		r.settled.Store(true)
+		res := &bridgepb.ControlResultFrame{ControlId: r.Control.ID, Ok: err == nil, Affected: int32(affected)}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		r.err = r.replyFn(ctx, res)
+	})
+	return r.err
+}
+
+// Ack replies success without an affected count.
+func (r *ControlRequest) Ack(ctx context.Context) error {
+	return r.Reply(ctx, 0, nil)
+}
+
+// Nack replies failure with reason.
+func (r *ControlRequest) Nack(ctx context.Context, reason string) error {
+	return r.Reply(ctx, 0, errors.New(reason))
+}
+
+func (r *ControlRequest) ref() string {
+	return r.Control.ID
+}
+
+func (r *ControlRequest) isSettled() bool {
+	return r.settled.Load()
+}
+
+// SubscribeControl returns a channel of control requests; each must be
+// answered with Reply, Ack or Nack.
+func (c *client) SubscribeControl(ctx context.Context) (<-chan *ControlRequest, error) {
+	return subscribe(c, ctx, c.controlCh, &c.controlConsumers)
+}
+
+// HandleControl applies controls with fn and replies with its result.
+// opts.AckMode is ignored: the result of fn is always sent.
+func (c *client) HandleControl(fn ControlFunc, opts HandleOptions) error {
+	if fn == nil {
+		return errors.New("control handler is required")
+	}
+	opts.AckMode = AckManual
+	return handle(c, c.controlCh, &c.controlConsumers, opts, func(ctx context.Context, req *ControlRequest) error {
+		affected, err := fn(ctx, req.Control)
+		return req.Reply(context.WithoutCancel(ctx), affected, err)
+	})
+}
+
+// dispatchControl queues a control, answering it directly when nobody consumes controls.
+func (c *client) dispatchControl(ctx context.Context, f *bridgepb.ControlFrame) bool {
+	req := &ControlRequest{
+		Control: controlFromFrame(f),
+		replyFn: func(ctx context.Context, res *bridgepb.ControlResultFrame) error {
+			return c.queueAck(ctx, &bridgepb.StreamRequest{Payload: &bridgepb.StreamRequest_ControlResult{ControlResult: res}})
+		},
+	}
+	if c.controlConsumers.Load() == 0 {
+		_ = req.Nack(ctx, "no control handler")
+		return true
+	}
+	return enqueue(ctx, c.controlCh, &c.controlConsumers, req)
+}
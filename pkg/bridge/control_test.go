@@ -0,0 +1,120 @@
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+// TestControlReplySaturated sends a control for every ingress frame while
+// the worker echoes Delivers from its receive loop and several publishers
+// keep the client stream full. Control results, whether a Nack from the
+// client's receive loop or a HandleControl reply, must not wait behind the
+// publishers holding the send lock.
+func TestControlReplySaturated(t *testing.T) {
+	for _, handled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("handled=%t", handled), func(t *testing.T) {
+			testControlReplySaturated(t, handled)
+		})
+	}
+}
+
+func testControlReplySaturated(t *testing.T, handled bool) {
+	const (
+		publishers = 8
+		perPub     = 100
+		payload    = 64 << 10
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	addr := fmt.Sprintf("inproc://bridge-control-%d", benchAddrSeq.Add(1))
+	opts := bridge.Options{Address: addr, Insecure: true, NodeID: "ctl", Namespace: "ctl", DeliverBuffer: 1, ControlTimeout: time.Minute}
+	srv, err := bridge.NewServer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	results := make(chan error, publishers*perPub)
+	onIngress := func(ctx context.Context, s bridge.Session, env envelope.TransportEnvelope) error {
+		go func() {
+			res, err := s.SendControl(context.WithoutCancel(ctx), bridge.Control{Command: bridge.ControlKick, ConnectionIDs: []string{benchConnID}})
+			if err == nil && res.OK != handled {
+				err = fmt.Errorf("control result ok=%t (%s)", res.OK, res.Error)
+			}
+			results <- err
+		}()
+		return echoIngress(ctx, s, env)
+	}
+	w := &benchWorker{sessions: make(chan bridge.Session, 1), onIngress: onIngress}
+	go func() { _ = srv.Serve(ctx, w) }()
+
+	client, err := bridge.NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	err = client.HandleDeliver(func(ctx context.Context, d *bridge.Delivery) error {
+		return nil
+	}, bridge.HandleOptions{AckTimeout: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handled {
+		err = client.HandleControl(func(ctx context.Context, ctl bridge.Control) (int, error) {
+			return len(ctl.ConnectionIDs), nil
+		}, bridge.HandleOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := waitStarted(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+
+	text := strings.Repeat("x", payload)
+	var wg sync.WaitGroup
+	errs := make(chan error, publishers)
+	for p := range publishers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perPub {
+				err := client.PublishIngress(ctx, envelope.TransportEnvelope{
+					ConnectionId: benchConnID,
+					Message: &envelope.Message{
+						Action:    "bench.echo",
+						RequestId: fmt.Sprintf("r-%d-%d", p, i),
+						Payload:   &envelope.Payload{Text: &envelope.TextPayload{Content: text}},
+					},
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for range publishers * perPub {
+		select {
+		case err := <-results:
+			if err != nil {
+				t.Fatalf("control: %v", err)
+			}
+		case err := <-errs:
+			t.Fatalf("publish: %v", err)
+		case <-ctx.Done():
+			t.Fatal("client and worker deadlocked replying to controls on a saturated stream")
+		}
+	}
+	wg.Wait()
+}
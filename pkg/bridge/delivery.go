@@ -3,39 +3,77 @@ package bridge
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Goden-Gun/transport-lib/pkg/envelope"
 )
 
+// Ack statuses carried by AckFrame.status.
+const (
+	AckStatusOK   = "ok"
+	AckStatusNack = "nack"
+)
+
+// ackFunc sends an AckFrame with the given status and reason.
+type ackFunc func(ctx context.Context, status, reason string) error
+
+// settlement guards the single ack or nack of a delivery.
+type settlement struct {
+	ackFn ackFunc
+
+	once    sync.Once
+	err     error
+	settled atomic.Bool
+}
+
+func (s *settlement) settle(ctx context.Context, status, reason string) error {
+	if s.ackFn == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.once.Do(func() {
+		s.settled.Store(true)
+		s.err = s.ackFn(ctx, status, reason)
+	})
+	return s.err
+}
+
 // Delivery wraps a transport envelope along with its ACK promise.
 type Delivery struct {
 	Envelope *envelope.TransportEnvelope
 
-	ackFn func(context.Context) error
-
-	ackOnce sync.Once
-	ackErr  error
+	settlement
 }
 
-func newDelivery(env *envelope.TransportEnvelope, ackFn func(context.Context) error) *Delivery {
+func newDelivery(env *envelope.TransportEnvelope, ackFn ackFunc) *Delivery {
 	return &Delivery{
-		Envelope: env,
-		ackFn:    ackFn,
+		Envelope:   env,
+		settlement: settlement{ackFn: ackFn},
 	}
 }
 
-// Ack confirms the delivery back to the bridge server.
+// Ack confirms the delivery back to the bridge server. The ack is queued
+// and written in the background, so Ack never waits on the stream.
 func (d *Delivery) Ack(ctx context.Context) error {
-	if d == nil || d.ackFn == nil {
+	if d == nil {
 		return nil
 	}
-	if ctx == nil {
-		ctx = context.Background()
+	return d.settle(ctx, AckStatusOK, "")
+}
+
+// Nack reports a failed delivery back to the bridge server. Only the first of
+// Ack or Nack is sent.
+func (d *Delivery) Nack(ctx context.Context, reason string) error {
+	if d == nil {
+		return nil
 	}
-	d.ackOnce.Do(func() {
-		d.ackErr = d.ackFn(ctx)
-	})
-	return d.ackErr
+	return d.settle(ctx, AckStatusNack, reason)
+}
+
+func (d *Delivery) ref() string {
+	return d.Envelope.GetMessage().GetRequestId()
 }
 
 // BroadcastDelivery wraps a broadcast envelope that expects ACK.
@@ -43,26 +81,30 @@ type BroadcastDelivery struct {
 	Envelope    *envelope.TransportEnvelope
 	BroadcastID string
 
-	ackFn func(context.Context) error
-
-	ackOnce sync.Once
-	ackErr  error
+	settlement
 }
 
-func newBroadcastDelivery(env *envelope.TransportEnvelope, broadcastID string, ackFn func(context.Context) error) *BroadcastDelivery {
-	return &BroadcastDelivery{Envelope: env, BroadcastID: broadcastID, ackFn: ackFn}
+func newBroadcastDelivery(env *envelope.TransportEnvelope, broadcastID string, ackFn ackFunc) *BroadcastDelivery {
+	return &BroadcastDelivery{Envelope: env, BroadcastID: broadcastID, settlement: settlement{ackFn: ackFn}}
 }
 
 // Ack confirms the broadcast delivery back to the bridge server.
 func (d *BroadcastDelivery) Ack(ctx context.Context) error {
-	if d == nil || d.ackFn == nil {
+	if d == nil {
 		return nil
 	}
-	if ctx == nil {
-		ctx = context.Background()
+	return d.settle(ctx, AckStatusOK, "")
+}
+
+// Nack reports a failed broadcast delivery back to the bridge server. Only the
+// first of Ack or Nack is sent.
+func (d *BroadcastDelivery) Nack(ctx context.Context, reason string) error {
+	if d == nil {
+		return nil
 	}
-	d.ackOnce.Do(func() {
-		d.ackErr = d.ackFn(ctx)
-	})
-	return d.ackErr
+	return d.settle(ctx, AckStatusNack, reason)
+}
+
+func (d *BroadcastDelivery) ref() string {
+	return d.BroadcastID
 }
@@ -0,0 +1,100 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+	"github.com/Goden-Gun/transport-lib/pkg/codes"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
+)
+
+// ErrorPolicy controls how Handler errors affect the sidecar stream.
+type ErrorPolicy int
+
+const (
+	// ErrorPolicyReply answers a failed OnIngress with a Deliver carrying an
+	// ErrorPayload to the originating connection and keeps the stream open.
+	// Only errors wrapped with Fatal close the stream.
+	ErrorPolicyReply ErrorPolicy = iota
+	// ErrorPolicyClose closes the stream on any handler error.
+	ErrorPolicyClose
+)
+
+// Fatal marks a handler error as fatal: the stream is closed regardless of
+// Options.HandlerErrorPolicy.
+func Fatal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &fatalError{err: err}
+}
+
+// IsFatal reports whether err was wrapped with Fatal.
+func IsFatal(err error) bool {
+	var fe *fatalError
+	return errors.As(err, &fe)
+}
+
+type fatalError struct {
+	err error
+}
+
+func (e *fatalError) Error() string { return e.err.Error() }
+func (e *fatalError) Unwrap() error { return e.err }
+
+// ErrorReply builds a response envelope for src carrying err mapped through
+// pkg/codes. It targets the originating connection and keeps the action,
+// request and conversation ids so clients can correlate it. Details are only
+// those attached with codes.WithDetails: causes wrapped with codes.Wrap may
+// carry internal text and are left for the server's logs.
+func ErrorReply(src *envelope.TransportEnvelope, err error) *envelope.TransportEnvelope {
+	code := codes.FromError(err)
+	payload := &envelope.ErrorPayload{Code: code.Numeric, ErrorCode: code.Symbol, Message: code.Message, Details: codes.Details(err)}
+	return replyTo(src, &envelope.Message{Error: payload})
+}
+
+// replyTo addresses msg as a response to src: it targets the originating
+// connection and copies the action, request and conversation ids.
+func replyTo(src *envelope.TransportEnvelope, msg *envelope.Message) *envelope.TransportEnvelope {
+	msg.Kind = "response"
+	if m := src.GetMessage(); m != nil {
+		msg.Action = m.Action
+		msg.RequestId = m.RequestId
+		msg.ConversationId = m.ConversationId
+	}
+	reply := &envelope.TransportEnvelope{
+		ConnectionId: src.GetConnectionId(),
+		UserId:       src.GetUserId(),
+		Namespace:    src.GetNamespace(),
+		TraceId:      src.GetTraceId(),
+		Message:      msg,
+	}
+	if src.GetConnectionId() != "" {
+		reply.TargetConnectionIds = []string{src.GetConnectionId()}
+	}
+	envelope.NormalizeEnvelope(reply)
+	return reply
+}
+
+// handleError applies the error policy. A non-nil result closes the stream.
+func (svc *bridgeService) handleError(ctx context.Context, sess *session, src *envelope.TransportEnvelope, err error) error {
+	if err == nil {
+		return nil
+	}
+	opts := svc.srv.opts
+	if opts.HandlerErrorPolicy == ErrorPolicyClose || IsFatal(err) || ctx.Err() != nil {
+		return err
+	}
+	if opts.OnHandlerError != nil {
+		opts.OnHandlerError(ctx, sess, err)
+	} else {
+		logger.WithTrace(ctx).WithError(err).WithField("node_id", sess.meta.NodeID).Warn("bridge handler error")
+	}
+	if src == nil {
+		return nil
+	}
+	reply := ErrorReply(src, err)
+	return sess.send(ctx, &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Deliver{Deliver: &bridgepb.DeliverFrame{Envelope: reply}}})
+}
@@ -0,0 +1,73 @@
+package bridge
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	// defaultKeepaliveMinTime matches the config default; gRPC's own 5m would
+	// end streams of clients pinging at the default 30s with too_many_pings.
+	defaultKeepaliveMinTime = 10 * time.Second
+	// frameOverhead leaves room for the ChunkFrame envelope around chunk data.
+	frameOverhead = 1024
+)
+
+// capFrameSize keeps chunked frames below the configured send limit.
+func capFrameSize(opts *Options) {
+	if opts.MaxSendMsgSize > 0 && opts.MaxFrameSize > opts.MaxSendMsgSize-frameOverhead {
+		opts.MaxFrameSize = opts.MaxSendMsgSize - frameOverhead
+		if opts.MaxFrameSize <= 0 {
+			opts.MaxFrameSize = opts.MaxSendMsgSize / 2
+		}
+	}
+}
+
+func (o Options) dialOptions() []grpc.DialOption {
+	var out []grpc.DialOption
+	var callOpts []grpc.CallOption
+	if o.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(o.MaxSendMsgSize))
+	}
+	if o.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(o.MaxRecvMsgSize))
+	}
+	if len(callOpts) > 0 {
+		out = append(out, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	if o.KeepaliveTime > 0 || o.KeepaliveTimeout > 0 || o.KeepalivePermitWithoutStream {
+		out = append(out, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                o.KeepaliveTime,
+			Timeout:             o.KeepaliveTimeout,
+			PermitWithoutStream: o.KeepalivePermitWithoutStream,
+		}))
+	}
+	return append(out, o.DialOptions...)
+}
+
+func (o Options) serverOptions() []grpc.ServerOption {
+	var out []grpc.ServerOption
+	if o.MaxSendMsgSize > 0 {
+		out = append(out, grpc.MaxSendMsgSize(o.MaxSendMsgSize))
+	}
+	if o.MaxRecvMsgSize > 0 {
+		out = append(out, grpc.MaxRecvMsgSize(o.MaxRecvMsgSize))
+	}
+	if o.KeepaliveTime > 0 || o.KeepaliveTimeout > 0 {
+		out = append(out, grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    o.KeepaliveTime,
+			Timeout: o.KeepaliveTimeout,
+		}))
+	}
+	minTime := o.KeepaliveMinTime
+	if minTime <= 0 {
+		minTime = defaultKeepaliveMinTime
+	}
+	out = append(out, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             minTime,
+		PermitWithoutStream: o.KeepalivePermitWithoutStream,
+	}))
+	return append(out, o.ServerOptions...)
+}
@@ -0,0 +1,147 @@
+package bridge
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+)
+
+const defaultHealthCheckInterval = 10 * time.Second
+
+// HealthServiceName is the service name reported by the bridge health status.
+var HealthServiceName = bridgepb.SidecarBridge_ServiceDesc.ServiceName
+
+// HealthCheck reports whether a dependency (Redis, Kafka, ...) is usable.
+// A non-nil error marks the bridge NOT_SERVING until the next successful run.
+type HealthCheck func(ctx context.Context) error
+
+// healthReporter drives grpc.health.v1 status from server state and checks.
+type healthReporter struct {
+	hs       *health.Server
+	owned    bool
+	interval time.Duration
+
+	mu       sync.Mutex
+	checks   map[string]HealthCheck
+	serving  bool
+	draining bool
+	failing  string
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func newHealthReporter(opts Options) *healthReporter {
+	r := &healthReporter{
+		hs:       opts.HealthServer,
+		interval: opts.HealthCheckInterval,
+		checks:   map[string]HealthCheck{},
+		stop:     make(chan struct{}),
+	}
+	if r.hs == nil {
+		r.hs = health.NewServer()
+		r.owned = true
+	}
+	if r.interval <= 0 {
+		r.interval = defaultHealthCheckInterval
+	}
+	r.publish()
+	return r
+}
+
+// register exposes the health service (when owned) and, if enabled,
+// reflection on registrar.
+func (r *healthReporter) register(registrar grpc.ServiceRegistrar, enableReflection bool) {
+	if r.owned {
+		healthpb.RegisterHealthServer(registrar, r.hs)
+	}
+	if enableReflection {
+		if rs, ok := registrar.(reflection.GRPCServer); ok {
+			reflection.Register(rs)
+		}
+	}
+}
+
+func (r *healthReporter) add(name string, check HealthCheck) {
+	r.mu.Lock()
+	if check == nil {
+		delete(r.checks, name)
+	} else {
+		r.checks[name] = check
+	}
+	r.mu.Unlock()
+}
+
+// start marks the bridge serving and runs checks every interval until shutdown.
+func (r *healthReporter) start() {
+	r.mu.Lock()
+	r.serving = true
+	r.mu.Unlock()
+	r.runChecks()
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.runChecks()
+			}
+		}
+	}()
+}
+
+// drain reports NOT_SERVING for the rest of the server's life.
+func (r *healthReporter) drain() {
+	r.stopOnce.Do(func() { close(r.stop) })
+	r.mu.Lock()
+	r.draining = true
+	r.mu.Unlock()
+	r.publish()
+}
+
+func (r *healthReporter) runChecks() {
+	r.mu.Lock()
+	checks := make(map[string]HealthCheck, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mu.Unlock()
+
+	failing := ""
+	for name, check := range checks {
+		ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+		err := check(ctx)
+		cancel()
+		if err != nil {
+			failing = name
+			break
+		}
+	}
+	r.mu.Lock()
+	r.failing = failing
+	r.mu.Unlock()
+	r.publish()
+}
+
+func (r *healthReporter) publish() {
+	r.mu.Lock()
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if r.serving && !r.draining && r.failing == "" {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	r.mu.Unlock()
+	r.hs.SetServingStatus(HealthServiceName, status)
+	if r.owned {
+		// The overall ("") status belongs to whoever owns the health service.
+		r.hs.SetServingStatus("", status)
+	}
+}
@@ -0,0 +1,240 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
+)
+
+const (
+	// presenceCallTimeout bounds each Presence call made for a session.
+	presenceCallTimeout = 5 * time.Second
+	// defaultPresenceRefresh spaces heartbeat refreshes when Presence does
+	// not report its own interval.
+	defaultPresenceRefresh = 30 * time.Second
+)
+
+var (
+	// ErrNoPresence indicates Server.Deliver was used without Options.Presence.
+	ErrNoPresence = errors.New("presence directory not configured")
+	// ErrNodeNotConnected indicates a user's sidecar node has no session on this server.
+	ErrNodeNotConnected = errors.New("sidecar node not connected")
+)
+
+// Presence is the user directory the server keeps up to date from connection
+// events and heartbeats, and consults to route Server.Deliver.
+// *presence.Directory implements it.
+type Presence interface {
+	Register(ctx context.Context, nodeID string, userID int64, connectionID string) error
+	Unregister(ctx context.Context, nodeID string, userID int64, connectionID string) error
+	Refresh(ctx context.Context, nodeID string) error
+	// NodesForUsers groups users by the nodes holding at least one of their connections.
+	NodesForUsers(ctx context.Context, userIDs []int64) (map[string][]int64, error)
+}
+
+// presenceRefresher is implemented by a Presence that reports how often a
+// node's entries must be refreshed; heartbeats refresh at most that often.
+type presenceRefresher interface {
+	RefreshInterval() time.Duration
+}
+
+// Deliver routes env to the sessions of the sidecar nodes holding its target
+// users (TargetUserIds, or UserId when empty) according to Options.Presence.
+// Each node receives a copy narrowed to its own users; offline users are
+// skipped. It returns the number of sessions written to. Session.SendDeliver
+// does not consult Presence: it writes to that one session whatever the
+// envelope targets.
+func (s *server) Deliver(ctx context.Context, env *envelope.TransportEnvelope) (int, error) {
+	if s.opts.Presence == nil {
+		return 0, ErrNoPresence
+	}
+	users := env.GetTargetUserIds()
+	if len(users) == 0 && env.GetUserId() != 0 {
+		users = []int64{env.GetUserId()}
+	}
+	if len(users) == 0 {
+		return 0, errors.New("deliver requires target users")
+	}
+	nodes, err := s.opts.Presence.NodesForUsers(ctx, users)
+	if err != nil {
+		return 0, fmt.Errorf("presence lookup: %w", err)
+	}
+	sent := 0
+	var errs []error
+	for nodeID, nodeUsers := range nodes {
+		sess := s.sessionForNode(nodeID)
+		if sess == nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNodeNotConnected, nodeID))
+			continue
+		}
+		out := proto.Clone(env).(*envelope.TransportEnvelope)
+		out.TargetUserIds = nodeUsers
+		envelope.NormalizeEnvelope(out)
+		resp := &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Deliver{Deliver: &bridgepb.DeliverFrame{Envelope: out}}}
+		if err := sess.send(ctx, resp); err != nil {
+			errs = append(errs, fmt.Errorf("deliver to %s: %w", nodeID, err))
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// sessionForNode returns the most recently connected session of nodeID.
+func (s *server) sessionForNode(nodeID string) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found *session
+	for sess := range s.sessions {
+		if sess.meta.NodeID != nodeID {
+			continue
+		}
+		if found == nil || sess.connectedAt.After(found.connectedAt) {
+			found = sess
+		}
+	}
+	return found
+}
+
+// presenceUpdater applies a session's presence updates off its receive loop.
+// Connection events are applied in arrival order and heartbeat refreshes are
+// coalesced and spaced by interval; updates queued when the session ends are
+// still applied.
+type presenceUpdater struct {
+	srv      *server
+	sess     *session
+	interval time.Duration
+
+	mu      sync.Mutex
+	events  []ConnectionEvent
+	refresh bool
+	closed  bool
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+// presenceUpdater starts the updater of sess, or returns nil when
+// Options.Presence is not set; a nil updater ignores updates.
+func (s *server) presenceUpdater(sess *session) *presenceUpdater {
+	if s.opts.Presence == nil {
+		return nil
+	}
+	u := &presenceUpdater{srv: s, sess: sess, interval: defaultPresenceRefresh, wake: make(chan struct{}, 1), done: make(chan struct{})}
+	if r, ok := s.opts.Presence.(presenceRefresher); ok && r.RefreshInterval() > 0 {
+		u.interval = r.RefreshInterval()
+	}
+	go u.run()
+	return u
+}
+
+// track queues event for Options.Presence.
+func (u *presenceUpdater) track(event ConnectionEvent) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	u.events = append(u.events, event)
+	u.mu.Unlock()
+	u.signal()
+}
+
+// touch queues a refresh of the node's presence entries.
+func (u *presenceUpdater) touch() {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	u.refresh = true
+	u.mu.Unlock()
+	u.signal()
+}
+
+// close applies the remaining updates and stops the updater.
+func (u *presenceUpdater) close() {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	u.closed = true
+	u.mu.Unlock()
+	u.signal()
+	<-u.done
+}
+
+func (u *presenceUpdater) signal() {
+	select {
+	case u.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (u *presenceUpdater) run() {
+	defer close(u.done)
+	// Updates outlive the stream: an Unregister for a closing connection must still land.
+	ctx := context.WithoutCancel(u.sess.ctx)
+	var lastRefresh time.Time
+	for range u.wake {
+		u.mu.Lock()
+		events, refresh, closed := u.events, u.refresh, u.closed
+		u.events, u.refresh = nil, false
+		u.mu.Unlock()
+		for _, event := range events {
+			u.srv.trackPresence(ctx, u.sess, event)
+		}
+		// Heartbeats come more often than entries need refreshing; a
+		// skipped one is picked up by the next heartbeat after interval.
+		if refresh && time.Since(lastRefresh) >= u.interval {
+			lastRefresh = time.Now()
+			u.srv.refreshPresence(ctx, u.sess)
+		}
+		if closed {
+			return
+		}
+	}
+}
+
+// trackPresence mirrors a connection event into Options.Presence.
+func (s *server) trackPresence(ctx context.Context, sess *session, event ConnectionEvent) {
+	p := s.opts.Presence
+	if p == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, presenceCallTimeout)
+	defer cancel()
+	var err error
+	switch event.Type {
+	case ConnectionAuthChanged:
+		if prev, parseErr := strconv.ParseInt(event.Attributes[AttrPreviousUserID], 10, 64); parseErr == nil && prev != event.UserID {
+			_ = p.Unregister(ctx, sess.meta.NodeID, prev, event.ConnectionID)
+		}
+		err = p.Register(ctx, sess.meta.NodeID, event.UserID, event.ConnectionID)
+	case ConnectionOpened:
+		err = p.Register(ctx, sess.meta.NodeID, event.UserID, event.ConnectionID)
+	case ConnectionClosed:
+		err = p.Unregister(ctx, sess.meta.NodeID, event.UserID, event.ConnectionID)
+	}
+	if err != nil {
+		logger.WithTrace(ctx).WithError(err).WithField("connection_id", event.ConnectionID).Warn("bridge presence update failed")
+	}
+}
+
+// refreshPresence extends the node's presence entries on heartbeat.
+func (s *server) refreshPresence(ctx context.Context, sess *session) {
+	if s.opts.Presence == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, presenceCallTimeout)
+	defer cancel()
+	if err := s.opts.Presence.Refresh(ctx, sess.meta.NodeID); err != nil {
+		logger.WithTrace(ctx).WithError(err).WithField("node_id", sess.meta.NodeID).Warn("bridge presence refresh failed")
+	}
+}
@@ -0,0 +1,359 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+const (
+	defaultRecordMaxBytes = 64 << 20
+	defaultRecordMaxFiles = 5
+	defaultRecordQueue    = 1024
+	redactedValue         = "[redacted]"
+)
+
+// RecordFormat selects the on-disk encoding of a recording.
+type RecordFormat string
+
+const (
+	// RecordJSONL writes one protojson RecordedFrame per line.
+	RecordJSONL RecordFormat = "jsonl"
+	// RecordProto writes varint length-delimited binary RecordedFrames.
+	RecordProto RecordFormat = "proto"
+)
+
+// Recording sides.
+const (
+	RecordSideClient = "client"
+	RecordSideServer = "server"
+)
+
+// RecorderOptions configure NewRecorder.
+type RecorderOptions struct {
+	// Path is the active recording file; rotated files are Path.1 … Path.MaxFiles.
+	Path string
+	// Format defaults to RecordJSONL.
+	Format RecordFormat
+	// MaxBytes rotates the file once it exceeds this size (default 64 MiB, negative disables).
+	MaxBytes int64
+	// MaxFiles is the number of rotated files kept (default 5).
+	MaxFiles int
+	// QueueSize is the number of frames buffered for the writer goroutine
+	// (default 1024); frames recorded while it is full are dropped.
+	QueueSize int
+	// RedactPayloads replaces text content and the values of message metadata
+	// and extras, and clears audio/binary bytes.
+	RedactPayloads bool
+	// RedactAttributes lists envelope attribute keys whose values are replaced.
+	RedactAttributes []string
+	// Redact is applied to every recorded envelope after the built-in redaction.
+	Redact func(env *envelope.TransportEnvelope)
+}
+
+// Recorder writes StreamRequest/StreamResponse frames to a rotating file.
+// Set it as Options.Recorder on a client or server; frames are recorded
+// after chunk reassembly and before splitting. Frames are encoded by the
+// sender and written by a separate goroutine, so a slow disk never holds up
+// a stream. A Recorder may be shared.
+type Recorder struct {
+	opts    RecorderOptions
+	queue   chan []byte
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	// file and size are owned by the writer goroutine.
+	file *os.File
+	size int64
+
+	mu       sync.Mutex
+	closed   bool
+	err      error
+	closeErr error
+}
+
+// NewRecorder opens (appending to) opts.Path.
+func NewRecorder(opts RecorderOptions) (*Recorder, error) {
+	if opts.Path == "" {
+		return nil, errors.New("recording path is required")
+	}
+	switch opts.Format {
+	case "":
+		opts.Format = RecordJSONL
+	case RecordJSONL, RecordProto:
+	default:
+		return nil, fmt.Errorf("unsupported recording format %q", opts.Format)
+	}
+	if opts.MaxBytes == 0 {
+		opts.MaxBytes = defaultRecordMaxBytes
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = defaultRecordMaxFiles
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultRecordQueue
+	}
+	r := &Recorder{opts: opts, queue: make(chan []byte, opts.QueueSize), done: make(chan struct{})}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	go r.run()
+	return r, nil
+}
+
+// Err returns the first write error; recording stops after it.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Dropped returns the number of frames dropped because the queue was full.
+func (r *Recorder) Dropped() uint64 {
+	return r.dropped.Load()
+}
+
+// Close writes the queued frames and closes the recording file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+	<-r.done
+	return r.closeErr
+}
+
+func (r *Recorder) recordRequest(side, nodeID, sessionID string, req *bridgepb.StreamRequest) {
+	if r == nil {
+		return
+	}
+	if env := req.GetIngress().GetEnvelope(); env != nil && r.redacts() {
+		req = proto.Clone(req).(*bridgepb.StreamRequest)
+		r.redact(req.GetIngress().GetEnvelope())
+	}
+	r.write(&bridgepb.RecordedFrame{NodeId: nodeID, SessionId: sessionID, Side: side, Frame: &bridgepb.RecordedFrame_Request{Request: req}})
+}
+
+func (r *Recorder) recordResponse(side, nodeID, sessionID string, resp *bridgepb.StreamResponse) {
+	if r == nil {
+		return
+	}
+	if r.redacts() {
+		switch resp.GetPayload().(type) {
+		case *bridgepb.StreamResponse_Deliver, *bridgepb.StreamResponse_Broadcast:
+			resp = proto.Clone(resp).(*bridgepb.StreamResponse)
+			r.redact(resp.GetDeliver().GetEnvelope())
+			r.redact(resp.GetBroadcast().GetEnvelope())
+		}
+	}
+	r.write(&bridgepb.RecordedFrame{NodeId: nodeID, SessionId: sessionID, Side: side, Frame: &bridgepb.RecordedFrame_Response{Response: resp}})
+}
+
+func (r *Recorder) redacts() bool {
+	return r.opts.RedactPayloads || len(r.opts.RedactAttributes) > 0 || r.opts.Redact != nil
+}
+
+func (r *Recorder) redact(env *envelope.TransportEnvelope) {
+	if env == nil {
+		return
+	}
+	if msg := env.GetMessage(); msg != nil && r.opts.RedactPayloads {
+		if p := msg.GetPayload(); p != nil {
+			if p.Text != nil {
+				p.Text.Content = redactedValue
+			}
+			if p.Audio != nil {
+				p.Audio.Data = ""
+				p.Audio.Raw = nil
+			}
+			if p.Binary != nil {
+				p.Binary.Data = nil
+			}
+		}
+		redactStruct(msg.GetMetadata())
+		redactStruct(msg.GetExtras())
+	}
+	for _, key := range r.opts.RedactAttributes {
+		if _, ok := env.Attributes[key]; ok {
+			env.Attributes[key] = redactedValue
+		}
+	}
+	if r.opts.Redact != nil {
+		r.opts.Redact(env)
+	}
+}
+
+// redactStruct replaces every value of s, keeping the keys.
+func redactStruct(s *structpb.Struct) {
+	for key := range s.GetFields() {
+		s.Fields[key] = structpb.NewStringValue(redactedValue)
+	}
+}
+
+func (r *Recorder) write(rec *bridgepb.RecordedFrame) {
+	rec.RecordedAt = timestamppb.Now()
+	var data []byte
+	var err error
+	if r.opts.Format == RecordJSONL {
+		data, err = protojson.Marshal(rec)
+		data = append(data, '\n')
+	} else {
+		var buf bytes.Buffer
+		_, err = protodelim.MarshalTo(&buf, rec)
+		data = buf.Bytes()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.err != nil {
+		return
+	}
+	if err != nil {
+		r.err = err
+		return
+	}
+	select {
+	case r.queue <- data:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// run writes queued frames until Close; after a write error the rest are discarded.
+func (r *Recorder) run() {
+	defer close(r.done)
+	for data := range r.queue {
+		if r.Err() != nil {
+			continue
+		}
+		if err := r.append(data); err != nil {
+			r.mu.Lock()
+			r.err = err
+			r.mu.Unlock()
+		}
+	}
+	if r.file != nil {
+		r.closeErr = r.file.Close()
+		r.file = nil
+	}
+}
+
+func (r *Recorder) append(data []byte) error {
+	if r.opts.MaxBytes > 0 && r.size > 0 && r.size+int64(len(data)) > r.opts.MaxBytes {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.file.Write(data)
+	r.size += int64(n)
+	return err
+}
+
+func (r *Recorder) open() error {
+	f, err := os.OpenFile(r.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate shifts Path.N to Path.N+1, dropping the oldest, and reopens Path.
+func (r *Recorder) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	_ = os.Remove(fmt.Sprintf("%s.%d", r.opts.Path, r.opts.MaxFiles))
+	for i := r.opts.MaxFiles - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", r.opts.Path, i), fmt.Sprintf("%s.%d", r.opts.Path, i+1))
+	}
+	if err := os.Rename(r.opts.Path, r.opts.Path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// RecordingReader reads a recording written by Recorder; the format is
+// detected from the first bytes.
+type RecordingReader struct {
+	r      *bufio.Reader
+	closer io.Closer
+	format RecordFormat
+}
+
+// OpenRecording opens a recording file.
+func OpenRecording(path string) (*RecordingReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	rr := NewRecordingReader(f)
+	rr.closer = f
+	return rr, nil
+}
+
+// NewRecordingReader reads a recording from r.
+func NewRecordingReader(r io.Reader) *RecordingReader {
+	br := bufio.NewReader(r)
+	format := RecordProto
+	// A binary record starts with its length then the recorded_at tag (0x0a).
+	if b, err := br.Peek(2); err == nil && b[0] == '{' && b[1] == '"' {
+		format = RecordJSONL
+	}
+	return &RecordingReader{r: br, format: format}
+}
+
+// Next returns the next recorded frame, or io.EOF at the end.
+func (rr *RecordingReader) Next() (*bridgepb.RecordedFrame, error) {
+	rec := &bridgepb.RecordedFrame{}
+	if rr.format == RecordProto {
+		if err := protodelim.UnmarshalFrom(rr.r, rec); err != nil {
+			return nil, err
+		}
+		return rec, nil
+	}
+	for {
+		line, err := rr.r.ReadString('\n')
+		if strings.TrimSpace(line) == "" {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if uerr := protojson.Unmarshal([]byte(line), rec); uerr != nil {
+			return nil, fmt.Errorf("decode recording line: %w", uerr)
+		}
+		return rec, nil
+	}
+}
+
+// Close closes the underlying file when opened with OpenRecording.
+func (rr *RecordingReader) Close() error {
+	if rr.closer == nil {
+		return nil
+	}
+	return rr.closer.Close()
+}
@@ -0,0 +1,192 @@
+package bridge
+
+import (
+	"sync"
+	"time"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+)
+
+const (
+	defaultReplayBufferSize = 1024
+	defaultReplayBufferTTL  = 5 * time.Minute
+	// resumeTokenHeader carries the worker's resume token in the stream response header.
+	resumeTokenHeader = "x-bridge-resume-token"
+	// ingressSeqHeader carries the highest StreamRequest.seq the worker
+	// received from the node, next to the resume token.
+	ingressSeqHeader = "x-bridge-ingress-seq"
+)
+
+// replayBuffer numbers the Deliver/Broadcast frames sent to one sidecar node
+// and retains the most recent ones so a reconnecting stream can resume.
+type replayBuffer struct {
+	mu     sync.Mutex
+	size   int
+	seq    uint64
+	frames []*bridgepb.StreamResponse
+	// ingress is the highest Ingress StreamRequest.seq received from the node.
+	ingress uint64
+
+	// sessions and evict are guarded by server.mu: the buffer is dropped
+	// ReplayBufferTTL after the node's last session closed.
+	sessions int
+	evict    *time.Timer
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{size: size}
+}
+
+// replayable reports whether resp is numbered and retained for resumption.
+func replayable(resp *bridgepb.StreamResponse) bool {
+	switch resp.GetPayload().(type) {
+	case *bridgepb.StreamResponse_Deliver, *bridgepb.StreamResponse_Broadcast:
+		return true
+	}
+	return false
+}
+
+// record returns a copy of resp stamped with the next sequence number and
+// retains it, evicting the oldest frame when full.
+func (b *replayBuffer) record(resp *bridgepb.StreamResponse) *bridgepb.StreamResponse {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	// Shallow copy: the payload may be shared with other sessions.
+	out := &bridgepb.StreamResponse{Payload: resp.Payload, Seq: b.seq}
+	if len(b.frames) == b.size {
+		copy(b.frames, b.frames[1:])
+		b.frames = b.frames[:len(b.frames)-1]
+	}
+	b.frames = append(b.frames, out)
+	return out
+}
+
+// since returns the retained frames after lastSeq and the number of frames
+// after lastSeq that were already evicted.
+func (b *replayBuffer) since(lastSeq uint64) ([]*bridgepb.StreamResponse, uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if lastSeq >= b.seq || len(b.frames) == 0 {
+		return nil, 0
+	}
+	oldest := b.frames[0].Seq
+	var lost uint64
+	if lastSeq+1 < oldest {
+		lost = oldest - lastSeq - 1
+	}
+	start := 0
+	if lastSeq >= oldest {
+		start = int(lastSeq - oldest + 1)
+	}
+	return append([]*bridgepb.StreamResponse(nil), b.frames[start:]...), lost
+}
+
+// acceptIngress reports whether an Ingress frame numbered seq is new, and
+// records it. Unnumbered frames are always accepted.
+func (b *replayBuffer) acceptIngress(seq uint64) bool {
+	if seq == 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if seq <= b.ingress {
+		return false
+	}
+	b.ingress = seq
+	return true
+}
+
+// resetIngress forgets the ingress position when a stream does not resume
+// (the sidecar numbers Ingress per client instance) and returns the one to
+// report in the response header.
+func (b *replayBuffer) resetIngress(resumed bool) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !resumed {
+		b.ingress = 0
+	}
+	return b.ingress
+}
+
+// ingressBuffer numbers a client's Ingress frames across its streams and
+// retains the most recent ones, so a resumed stream resends those the
+// worker did not receive. It is guarded by client.sendMu.
+type ingressBuffer struct {
+	size   int
+	seq    uint64
+	frames []*bridgepb.StreamRequest
+}
+
+func newIngressBuffer(size int) *ingressBuffer {
+	return &ingressBuffer{size: size}
+}
+
+// record stamps req with the next sequence number and retains it, evicting
+// the oldest frame when full.
+func (b *ingressBuffer) record(req *bridgepb.StreamRequest) {
+	b.seq++
+	req.Seq = b.seq
+	if len(b.frames) == b.size {
+		copy(b.frames, b.frames[1:])
+		b.frames = b.frames[:len(b.frames)-1]
+	}
+	b.frames = append(b.frames, req)
+}
+
+// since returns the retained frames after seq.
+func (b *ingressBuffer) since(seq uint64) []*bridgepb.StreamRequest {
+	for i, req := range b.frames {
+		if req.Seq > seq {
+			return b.frames[i:]
+		}
+	}
+	return nil
+}
+
+// replayFor returns the replay buffer of nodeID for a new session, or nil
+// when resumption is disabled. Each call is paired with releaseReplay.
+func (s *server) replayFor(nodeID string) *replayBuffer {
+	if s.opts.ReplayBufferSize < 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf := s.replays[nodeID]
+	if buf == nil {
+		buf = newReplayBuffer(s.opts.ReplayBufferSize)
+		s.replays[nodeID] = buf
+	}
+	buf.sessions++
+	if buf.evict != nil {
+		buf.evict.Stop()
+		buf.evict = nil
+	}
+	return buf
+}
+
+// releaseReplay drops sess's hold on its node's replay buffer and schedules
+// the buffer's eviction once no session of the node is left.
+func (s *server) releaseReplay(sess *session) {
+	buf := sess.replay
+	if buf == nil {
+		return
+	}
+	nodeID := sess.meta.NodeID
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buf.sessions--
+	if buf.sessions > 0 {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(s.opts.ReplayBufferTTL, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// A session that attached meanwhile stopped or replaced the timer.
+		if buf.evict == timer && s.replays[nodeID] == buf {
+			delete(s.replays, nodeID)
+		}
+	})
+	buf.evict = timer
+}
@@ -0,0 +1,305 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+// ErrReplayControl is returned by SendControl on sessions created by ReplayRequests.
+var ErrReplayControl = errors.New("control commands are not supported in replay")
+
+// ReplayOptions configure ReplayRequests and ReplayResponses.
+type ReplayOptions struct {
+	// Speed scales the recorded gaps between frames: 0 replays as fast as
+	// possible, 1 in real time, 2 twice as fast.
+	Speed float64
+	// NodeID and SessionID, when set, restrict replay to matching frames.
+	NodeID    string
+	SessionID string
+	// Side, when set, restricts replay to frames recorded by RecordSideClient
+	// or RecordSideServer; a Recorder shared by both ends records every frame
+	// twice.
+	Side string
+	// OnSend receives frames the handler sends on replayed sessions (ReplayRequests only).
+	OnSend func(sessionID string, resp *bridgepb.StreamResponse)
+}
+
+func (o ReplayOptions) matches(rec *bridgepb.RecordedFrame) bool {
+	return (o.NodeID == "" || rec.GetNodeId() == o.NodeID) &&
+		(o.SessionID == "" || rec.GetSessionId() == o.SessionID) &&
+		(o.Side == "" || rec.GetSide() == o.Side)
+}
+
+// replayClock sleeps for the recorded gap between consecutive frames.
+type replayClock struct {
+	speed float64
+	last  time.Time
+}
+
+func (c *replayClock) wait(ctx context.Context, rec *bridgepb.RecordedFrame) error {
+	at := rec.GetRecordedAt().AsTime()
+	defer func() { c.last = at }()
+	if c.speed <= 0 || c.last.IsZero() || !at.After(c.last) {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(time.Duration(float64(at.Sub(c.last)) / c.speed))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ReplayRequests feeds the recorded sidecar frames (StreamRequests) into
+// handler as if they arrived on live streams: one Session per recorded
+// session (or node), Register → OnRegister, Ingress → OnIngress, Ack →
+// OnAck, Heartbeat → OnHeartbeat and connection events to a
+// ConnectionEventHandler. Sessions are closed at the end of the recording.
+// Handler errors do not stop the replay; they are returned joined.
+func ReplayRequests(ctx context.Context, rr *RecordingReader, handler Handler, opts ReplayOptions) (err error) {
+	if handler == nil {
+		return errors.New("handler is required")
+	}
+	sessions := map[string]*replaySession{}
+	var order []*replaySession
+	var errs []error
+	clock := replayClock{speed: opts.Speed}
+	defer func() {
+		var closeErrs []error
+		for _, sess := range order {
+			if !sess.ended {
+				sess.cancel()
+				closeErrs = append(closeErrs, handler.OnClose(sess.ctx, sess))
+			}
+		}
+		err = errors.Join(append([]error{err}, closeErrs...)...)
+	}()
+	for {
+		rec, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			return errors.Join(errs...)
+		}
+		if err != nil {
+			return err
+		}
+		req := rec.GetRequest()
+		if req == nil || !opts.matches(rec) {
+			continue
+		}
+		if err := clock.wait(ctx, rec); err != nil {
+			return err
+		}
+		key := rec.GetSessionId()
+		if key == "" {
+			key = rec.GetNodeId()
+		}
+		sess := sessions[key]
+		if reg := req.GetRegister(); reg != nil || sess == nil {
+			if sess != nil {
+				sess.ended = true
+				sess.cancel()
+				errs = append(errs, handler.OnClose(sess.ctx, sess))
+			}
+			meta := RegisterMeta{NodeID: rec.GetNodeId()}
+			if reg != nil {
+				meta = RegisterMeta{NodeID: reg.GetNodeId(), Namespace: reg.GetNamespace(), Version: reg.GetBridgeVersion()}
+			}
+			sess = newReplaySession(ctx, rec.GetSessionId(), meta, opts.OnSend)
+			sessions[key] = sess
+			order = append(order, sess)
+			errs = append(errs, handler.OnRegister(sess.ctx, sess, meta))
+			if reg != nil {
+				continue
+			}
+		}
+		switch payload := req.GetPayload().(type) {
+		case *bridgepb.StreamRequest_Ingress:
+			if env := payload.Ingress.GetEnvelope(); env != nil {
+				errs = append(errs, handler.OnIngress(sess.ctx, sess, *env))
+			}
+		case *bridgepb.StreamRequest_Ack:
+			ack := Ack{MessageID: payload.Ack.GetMessageId(), BroadcastID: payload.Ack.GetBroadcastId(), Status: payload.Ack.GetStatus(), Reason: payload.Ack.GetReason()}
+			if ack.Status == "" {
+				ack.Status = AckStatusOK
+			}
+			errs = append(errs, handler.OnAck(sess.ctx, sess, ack))
+		case *bridgepb.StreamRequest_Heartbeat:
+			errs = append(errs, handler.OnHeartbeat(sess.ctx, sess, payload.Heartbeat.GetNonce()))
+		case *bridgepb.StreamRequest_ConnectionEvent:
+			if h, ok := handler.(ConnectionEventHandler); ok && payload.ConnectionEvent != nil {
+				errs = append(errs, h.OnConnectionEvent(sess.ctx, sess, connectionEventFromFrame(payload.ConnectionEvent)))
+			}
+		}
+	}
+}
+
+// ReplayResponses writes the recorded worker frames (StreamResponses) to
+// session, reproducing what a sidecar received; call it from
+// Handler.OnRegister of a test server. Frames go through the Session API:
+// broadcasts keep their ids and are tracked like live ones, recorded
+// nfc_sequence values are dropped so the server numbers Delivers afresh
+// (when OrderedDelivery is set). Controls are written without waiting for
+// the result, which OnRegister could not receive yet.
+// The stream seq is restamped by the session (or cleared when resumption is
+// off) so the client's resume position follows the new stream.
+func ReplayResponses(ctx context.Context, rr *RecordingReader, session Session, opts ReplayOptions) error {
+	clock := replayClock{speed: opts.Speed}
+	for {
+		rec, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp := rec.GetResponse()
+		if resp == nil || resp.GetChunk() != nil || !opts.matches(rec) {
+			continue
+		}
+		if err := clock.wait(ctx, rec); err != nil {
+			return err
+		}
+		if err := sendRecorded(ctx, session, resp); err != nil {
+			return err
+		}
+	}
+}
+
+func sendRecorded(ctx context.Context, sess Session, resp *bridgepb.StreamResponse) error {
+	switch payload := resp.GetPayload().(type) {
+	case *bridgepb.StreamResponse_Deliver:
+		if env := payload.Deliver.GetEnvelope(); env != nil {
+			env.NfcSequence = ""
+			return sess.SendDeliver(ctx, *env)
+		}
+	case *bridgepb.StreamResponse_Broadcast:
+		if env := payload.Broadcast.GetEnvelope(); env != nil {
+			if id := payload.Broadcast.GetBroadcastId(); id != "" && env.GetAttributes()[AttrBroadcastID] == "" {
+				if env.Attributes == nil {
+					env.Attributes = map[string]string{}
+				}
+				env.Attributes[AttrBroadcastID] = id
+			}
+			return sess.SendBroadcast(ctx, *env)
+		}
+	case *bridgepb.StreamResponse_Heartbeat:
+		return sess.SendHeartbeat(ctx, payload.Heartbeat.GetNonce())
+	case *bridgepb.StreamResponse_Control:
+		if s, ok := sess.(*session); ok {
+			return s.send(ctx, &bridgepb.StreamResponse{Payload: payload})
+		}
+		_, err := sess.SendControl(ctx, controlFromFrame(payload.Control))
+		return err
+	}
+	return nil
+}
+
+// replaySession is the Session handed to handlers by ReplayRequests.
+type replaySession struct {
+	id          string
+	meta        RegisterMeta
+	connectedAt time.Time
+	ctx         context.Context
+	cancel      context.CancelFunc
+	onSend      func(sessionID string, resp *bridgepb.StreamResponse)
+	// ended is set once the replayer called OnClose (a later Register replaced it).
+	ended bool
+
+	attrMu sync.RWMutex
+	attrs  map[string]any
+}
+
+func newReplaySession(ctx context.Context, id string, meta RegisterMeta, onSend func(string, *bridgepb.StreamResponse)) *replaySession {
+	if id == "" {
+		id = uuid.NewString()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &replaySession{id: id, meta: meta, connectedAt: time.Now(), ctx: ctx, cancel: cancel, onSend: onSend, attrs: map[string]any{}}
+}
+
+func (s *replaySession) emit(resp *bridgepb.StreamResponse) error {
+	if s.ctx.Err() != nil {
+		return ErrSessionClosed
+	}
+	if s.onSend != nil {
+		s.onSend(s.id, resp)
+	}
+	return nil
+}
+
+func (s *replaySession) SendDeliver(ctx context.Context, env envelope.TransportEnvelope) error {
+	envelope.NormalizeEnvelope(&env)
+	return s.emit(&bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Deliver{Deliver: &bridgepb.DeliverFrame{Envelope: &env}}})
+}
+
+func (s *replaySession) SendBroadcast(ctx context.Context, env envelope.TransportEnvelope) error {
+	envelope.NormalizeEnvelope(&env)
+	id := env.GetAttributes()[AttrBroadcastID]
+	if id == "" {
+		id = uuid.NewString()
+	}
+	return s.emit(&bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Broadcast{Broadcast: &bridgepb.BroadcastFrame{Envelope: &env, BroadcastId: id}}})
+}
+
+func (s *replaySession) SendHeartbeat(ctx context.Context, nonce string) error {
+	return s.emit(&bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Heartbeat{Heartbeat: &bridgepb.HeartbeatFrame{Nonce: nonce}}})
+}
+
+func (s *replaySession) SendControl(ctx context.Context, ctl Control) (ControlResult, error) {
+	return ControlResult{}, ErrReplayControl
+}
+
+func (s *replaySession) Metadata() RegisterMeta {
+	return s.meta
+}
+
+func (s *replaySession) ID() string {
+	return s.id
+}
+
+func (s *replaySession) RemoteAddr() net.Addr {
+	return nil
+}
+
+func (s *replaySession) ConnectedAt() time.Time {
+	return s.connectedAt
+}
+
+func (s *replaySession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *replaySession) Set(key string, value any) {
+	s.attrMu.Lock()
+	s.attrs[key] = value
+	s.attrMu.Unlock()
+}
+
+func (s *replaySession) Get(key string) (any, bool) {
+	s.attrMu.RLock()
+	defer s.attrMu.RUnlock()
+	value, ok := s.attrs[key]
+	return value, ok
+}
+
+func (s *replaySession) Delete(key string) {
+	s.attrMu.Lock()
+	delete(s.attrs, key)
+	s.attrMu.Unlock()
+}
+
+func (s *replaySession) Close() error {
+	s.cancel()
+	return nil
+}
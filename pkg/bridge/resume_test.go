@@ -0,0 +1,327 @@
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+const (
+	resumeTokenHeader = "x-bridge-resume-token"
+	ingressSeqHeader  = "x-bridge-ingress-seq"
+	resumeWait        = 10 * time.Second
+)
+
+func resumeIngress(seq uint64, requestID string) *bridgepb.StreamRequest {
+	return &bridgepb.StreamRequest{Seq: seq, Payload: &bridgepb.StreamRequest_Ingress{Ingress: &bridgepb.IngressFrame{Envelope: resumeEnvelope(requestID)}}}
+}
+
+func resumeDeliver(seq uint64, requestID string) *bridgepb.StreamResponse {
+	return &bridgepb.StreamResponse{Seq: seq, Payload: &bridgepb.StreamResponse_Deliver{Deliver: &bridgepb.DeliverFrame{Envelope: resumeEnvelope(requestID)}}}
+}
+
+func resumeEnvelope(requestID string) *envelope.TransportEnvelope {
+	return &envelope.TransportEnvelope{
+		ConnectionId:        benchConnID,
+		TargetConnectionIds: []string{benchConnID},
+		Message: &envelope.Message{
+			Action:    "resume.test",
+			RequestId: requestID,
+			Payload:   &envelope.Payload{Text: &envelope.TextPayload{Content: requestID}},
+		},
+	}
+}
+
+// nextRequestID waits for a request id on ch and fails unless it is want.
+func nextRequestID(t *testing.T, ch <-chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		if got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	case <-time.After(resumeWait):
+		t.Fatalf("timed out waiting for %s", want)
+	}
+}
+
+// noRequestID fails if anything arrives on ch shortly.
+func noRequestID(t *testing.T, ch <-chan string) {
+	t.Helper()
+	select {
+	case got := <-ch:
+		t.Fatalf("unexpected %s", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+// TestResumeWorker drives the worker with a raw stream: a resumed stream
+// gets the Deliver frames after last_seq from the replay buffer, and
+// Ingress frames the worker already received are dropped.
+func TestResumeWorker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := bridge.NewServer(bridge.Options{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	ingress := make(chan string, 16)
+	w := &benchWorker{sessions: make(chan bridge.Session, 2), onIngress: func(ctx context.Context, s bridge.Session, env envelope.TransportEnvelope) error {
+		ingress <- env.GetMessage().GetRequestId()
+		return nil
+	}}
+	go func() { _ = srv.(bridge.Embeddable).ServeListener(ctx, lis, w) }()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	api := bridgepb.NewSidecarBridgeClient(conn)
+	register := func(st bridgepb.SidecarBridge_StreamClient, token string, lastSeq uint64) metadata.MD {
+		t.Helper()
+		reg := &bridgepb.RegisterFrame{NodeId: "resume", Namespace: "resume", ResumeToken: token, LastSeq: lastSeq}
+		if err := st.Send(&bridgepb.StreamRequest{Payload: &bridgepb.StreamRequest_Register{Register: reg}}); err != nil {
+			t.Fatal(err)
+		}
+		md, err := st.Header()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return md
+	}
+	recvDeliver := func(st bridgepb.SidecarBridge_StreamClient) (uint64, string) {
+		t.Helper()
+		for {
+			resp, err := st.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := resp.GetDeliver(); d != nil {
+				return resp.GetSeq(), d.GetEnvelope().GetMessage().GetRequestId()
+			}
+		}
+	}
+
+	ctx1, cancel1 := context.WithCancel(ctx)
+	st1, err := api.Stream(ctx1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := register(st1, "", 0)
+	token := md.Get(resumeTokenHeader)
+	if len(token) != 1 || token[0] == "" {
+		t.Fatalf("resume token header %v", token)
+	}
+	sess := <-w.sessions
+	for i := uint64(1); i <= 2; i++ {
+		if err := st1.Send(resumeIngress(i, fmt.Sprintf("i%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nextRequestID(t, ingress, "i1")
+	nextRequestID(t, ingress, "i2")
+	noRequestID(t, ingress)
+	for i := 1; i <= 3; i++ {
+		if err := sess.SendDeliver(ctx, *resumeEnvelope(fmt.Sprintf("d%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := uint64(1); i <= 3; i++ {
+		if seq, id := recvDeliver(st1); seq != i || id != fmt.Sprintf("d%d", i) {
+			t.Fatalf("deliver %d: seq %d id %s", i, seq, id)
+		}
+	}
+	cancel1()
+
+	// Resume having processed only d1: d2 and d3 come from the replay buffer.
+	st2, err := api.Stream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md = register(st2, token[0], 1)
+	if got := md.Get(resumeTokenHeader); len(got) != 1 || got[0] != token[0] {
+		t.Fatalf("resumed token header %v, want %s", got, token[0])
+	}
+	if got := md.Get(ingressSeqHeader); len(got) != 1 || got[0] != "2" {
+		t.Fatalf("ingress seq header %v, want 2", got)
+	}
+	for i := uint64(2); i <= 3; i++ {
+		if seq, id := recvDeliver(st2); seq != i || id != fmt.Sprintf("d%d", i) {
+			t.Fatalf("replayed deliver %d: seq %d id %s", i, seq, id)
+		}
+	}
+	// i2 was received on the first stream and is dropped.
+	if err := st2.Send(resumeIngress(2, "i2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := st2.Send(resumeIngress(3, "i3")); err != nil {
+		t.Fatal(err)
+	}
+	nextRequestID(t, ingress, "i3")
+	noRequestID(t, ingress)
+}
+
+// rawWorker hands each stream to the test, which ends it through end.
+type rawWorker struct {
+	bridgepb.UnimplementedSidecarBridgeServer
+	streams chan rawStream
+}
+
+type rawStream struct {
+	bridgepb.SidecarBridge_StreamServer
+	end chan error
+}
+
+func (w *rawWorker) Stream(st bridgepb.SidecarBridge_StreamServer) error {
+	rs := rawStream{SidecarBridge_StreamServer: st, end: make(chan error, 1)}
+	w.streams <- rs
+	select {
+	case err := <-rs.end:
+		return err
+	case <-st.Context().Done():
+		return st.Context().Err()
+	}
+}
+
+// TestResumeClient drives the client with a raw worker: a resumed stream
+// resends the Ingress frames after the worker's acknowledged seq before new
+// ones, and Deliver frames the client already received are dropped.
+func TestResumeClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	w := &rawWorker{streams: make(chan rawStream, 2)}
+	bridgepb.RegisterSidecarBridgeServer(gs, w)
+	go func() { _ = gs.Serve(lis) }()
+	defer gs.Stop()
+
+	client, err := bridge.NewClient(bridge.Options{
+		Address:          lis.Addr().String(),
+		Insecure:         true,
+		NodeID:           "resume",
+		Namespace:        "resume",
+		ReconnectBackoff: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	delivered := make(chan string, 16)
+	err = client.HandleDeliver(func(ctx context.Context, d *bridge.Delivery) error {
+		delivered <- d.Envelope.GetMessage().GetRequestId()
+		return nil
+	}, bridge.HandleOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recvRegister := func(rs rawStream) *bridgepb.RegisterFrame {
+		t.Helper()
+		req, err := rs.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.GetRegister() == nil {
+			t.Fatalf("first frame %T, want register", req.GetPayload())
+		}
+		return req.GetRegister()
+	}
+	recvIngress := func(rs rawStream) (uint64, string) {
+		t.Helper()
+		for {
+			req, err := rs.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if in := req.GetIngress(); in != nil {
+				return req.GetSeq(), in.GetEnvelope().GetMessage().GetRequestId()
+			}
+		}
+	}
+	publish := func(id string) {
+		t.Helper()
+		deadline := time.Now().Add(resumeWait)
+		for {
+			err := client.PublishIngress(ctx, *resumeEnvelope(id))
+			if err == nil {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("publish %s: %v", id, err)
+			}
+			time.Sleep(benchWaitStep)
+		}
+	}
+
+	rs1 := <-w.streams
+	if reg := recvRegister(rs1); reg.GetResumeToken() != "" {
+		t.Fatalf("first register carries token %q", reg.GetResumeToken())
+	}
+	if err := rs1.SendHeader(metadata.Pairs(resumeTokenHeader, "t1", ingressSeqHeader, "0")); err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(1); i <= 2; i++ {
+		if err := rs1.Send(resumeDeliver(i, fmt.Sprintf("d%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nextRequestID(t, delivered, "d1")
+	nextRequestID(t, delivered, "d2")
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("i%d", i)
+		publish(id)
+		if seq, got := recvIngress(rs1); seq != uint64(i) || got != id {
+			t.Fatalf("ingress %d: seq %d id %s", i, seq, got)
+		}
+	}
+	rs1.end <- status.Error(codes.Unavailable, "worker restarting stream")
+
+	// The worker claims only i1, so i2 and i3 are resent ahead of i4.
+	rs2 := <-w.streams
+	reg := recvRegister(rs2)
+	if reg.GetResumeToken() != "t1" || reg.GetLastSeq() != 2 {
+		t.Fatalf("resume register token %q last_seq %d, want t1 and 2", reg.GetResumeToken(), reg.GetLastSeq())
+	}
+	if err := rs2.SendHeader(metadata.Pairs(resumeTokenHeader, "t1", ingressSeqHeader, "1")); err != nil {
+		t.Fatal(err)
+	}
+	publish("i4")
+	for i := 2; i <= 4; i++ {
+		id := fmt.Sprintf("i%d", i)
+		if seq, got := recvIngress(rs2); seq != uint64(i) || got != id {
+			t.Fatalf("resent ingress %d: seq %d id %s", i, seq, got)
+		}
+	}
+	// d2 was received on the first stream and is dropped.
+	for i := uint64(2); i <= 3; i++ {
+		if err := rs2.Send(resumeDeliver(i, fmt.Sprintf("d%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nextRequestID(t, delivered, "d3")
+	noRequestID(t, delivered)
+}
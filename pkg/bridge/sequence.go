@@ -0,0 +1,348 @@
+package bridge
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
+)
+
+const (
+	defaultReorderTimeout = 200 * time.Millisecond
+	sequenceIdleTTL       = 5 * time.Minute
+	reorderIdleTTL        = 2 * sequenceIdleTTL
+)
+
+// SequenceGap reports Deliver frames of a connection that never arrived
+// within Options.ReorderTimeout; delivery resumed at Received.
+type SequenceGap struct {
+	ConnectionID string
+	// Expected is the first missing sequence number.
+	Expected uint64
+	// Received is the sequence number delivery resumed at.
+	Received uint64
+}
+
+// Missing is the number of skipped frames.
+func (g SequenceGap) Missing() uint64 {
+	return g.Received - g.Expected
+}
+
+// sequenceKey returns the connection a Deliver envelope is ordered on: its
+// single target connection. Multi-target envelopes are not sequenced.
+func sequenceKey(env *envelope.TransportEnvelope) string {
+	if targets := env.GetTargetConnectionIds(); len(targets) == 1 {
+		return targets[0]
+	}
+	return ""
+}
+
+// sequencer assigns per-connection nfc_sequence numbers on the worker.
+type sequencer struct {
+	mu        sync.Mutex
+	next      map[string]*seqCounter
+	lastSweep time.Time
+}
+
+type seqCounter struct {
+	last     uint64
+	lastUsed time.Time
+}
+
+func newSequencer() *sequencer {
+	return &sequencer{next: map[string]*seqCounter{}, lastSweep: time.Now()}
+}
+
+// stamp sets env.NfcSequence unless already set or env is not single-target,
+// and reports whether it did.
+func (s *sequencer) stamp(env *envelope.TransportEnvelope) bool {
+	key := sequenceKey(env)
+	if s == nil || key == "" || env.NfcSequence != "" {
+		return false
+	}
+	now := time.Now()
+	s.mu.Lock()
+	s.sweep(now)
+	c := s.next[key]
+	if c == nil {
+		c = &seqCounter{}
+		s.next[key] = c
+	}
+	c.last++
+	c.lastUsed = now
+	seq := c.last
+	s.mu.Unlock()
+	env.NfcSequence = strconv.FormatUint(seq, 10)
+	return true
+}
+
+// release returns the number stamp gave env to its connection's counter
+// when the frame was not sent, unless a later number was handed out since.
+func (s *sequencer) release(env *envelope.TransportEnvelope) {
+	seq, err := strconv.ParseUint(env.NfcSequence, 10, 64)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	if c := s.next[sequenceKey(env)]; c != nil && c.last == seq {
+		c.last--
+	}
+	s.mu.Unlock()
+	env.NfcSequence = ""
+}
+
+// forget drops the counter of a closed connection.
+func (s *sequencer) forget(connectionID string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.next, connectionID)
+	s.mu.Unlock()
+}
+
+// sweep drops counters of connections idle for sequenceIdleTTL. The
+// client's reorderIdleTTL outlives it, so a client still holding the
+// connection sees the counter restart at 1. The caller holds mu.
+func (s *sequencer) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, c := range s.next {
+		if now.Sub(c.lastUsed) > sequenceIdleTTL {
+			delete(s.next, key)
+		}
+	}
+}
+
+// reorderBuffer releases sequenced deliveries of each connection in
+// nfc_sequence order, waiting up to timeout for missing frames. Deliveries
+// are emitted outside mu, under the connection's emit lock, so a blocked
+// consumer of one connection holds up neither others nor the timers.
+type reorderBuffer struct {
+	// ctx bounds releases triggered by the timeout rather than by push.
+	ctx     context.Context
+	timeout time.Duration
+	emit    func(ctx context.Context, d *Delivery) bool
+	onGap   func(SequenceGap)
+
+	mu        sync.Mutex
+	conns     map[string]*connOrder
+	lastSweep time.Time
+}
+
+type connOrder struct {
+	// emitMu serialises collecting and emitting ready deliveries; it is
+	// taken before reorderBuffer.mu.
+	emitMu sync.Mutex
+
+	next     uint64
+	pending  map[uint64]*Delivery
+	timer    *time.Timer
+	lastSeen time.Time
+}
+
+func newReorderBuffer(ctx context.Context, timeout time.Duration, onGap func(SequenceGap), emit func(ctx context.Context, d *Delivery) bool) *reorderBuffer {
+	if timeout <= 0 {
+		timeout = defaultReorderTimeout
+	}
+	if onGap == nil {
+		onGap = func(gap SequenceGap) {
+			logger.WithField("connection_id", gap.ConnectionID).WithField("expected", gap.Expected).
+				WithField("received", gap.Received).Warn("bridge deliver sequence gap")
+		}
+	}
+	return &reorderBuffer{ctx: ctx, timeout: timeout, emit: emit, onGap: onGap, conns: map[string]*connOrder{}, lastSweep: time.Now()}
+}
+
+// push releases d in order; unsequenced deliveries pass straight through.
+// A new connection expects sequence 1, like the worker's counter, and holds
+// later frames until the timeout; sequence 1 always restarts a connection
+// (a new counter on the worker). It returns false once ctx is done.
+func (b *reorderBuffer) push(ctx context.Context, d *Delivery) bool {
+	key := sequenceKey(d.Envelope)
+	seq, err := strconv.ParseUint(d.Envelope.GetNfcSequence(), 10, 64)
+	if key == "" || err != nil || seq == 0 {
+		return b.emit(ctx, d)
+	}
+	co := b.lockConn(key)
+	defer co.emitMu.Unlock()
+	var stale []*Delivery
+	if seq == 1 && co.next > 1 {
+		stale = co.restart()
+	}
+	switch {
+	case seq < co.next:
+		b.mu.Unlock()
+		logger.WithField("connection_id", key).WithField("seq", seq).Warn("bridge dropped stale deliver")
+		return true
+	case seq > co.next:
+		co.pending[seq] = d
+		if co.timer == nil {
+			co.timer = time.AfterFunc(b.timeout, func() { b.expire(key, co) })
+		}
+		b.mu.Unlock()
+		return b.emitAll(ctx, stale)
+	}
+	co.next++
+	ready := append(stale, d)
+	ready = append(ready, b.collect(key, co)...)
+	b.mu.Unlock()
+	return b.emitAll(ctx, ready)
+}
+
+// lockConn returns the state of key, creating it expecting sequence 1, with
+// its emitMu and b.mu held.
+func (b *reorderBuffer) lockConn(key string) *connOrder {
+	for {
+		now := time.Now()
+		b.mu.Lock()
+		b.sweep(now)
+		co := b.conns[key]
+		if co == nil {
+			co = &connOrder{next: 1, pending: map[uint64]*Delivery{}}
+			b.conns[key] = co
+		}
+		co.lastSeen = now
+		b.mu.Unlock()
+
+		co.emitMu.Lock()
+		b.mu.Lock()
+		if b.conns[key] == co {
+			return co
+		}
+		// Forgotten or swept meanwhile.
+		b.mu.Unlock()
+		co.emitMu.Unlock()
+	}
+}
+
+// restart flushes co's pending deliveries in order and resets its baseline
+// to 1; the caller holds mu.
+func (co *connOrder) restart() []*Delivery {
+	seqs := make([]uint64, 0, len(co.pending))
+	for seq := range co.pending {
+		seqs = append(seqs, seq)
+	}
+	slices.Sort(seqs)
+	out := make([]*Delivery, 0, len(seqs))
+	for _, seq := range seqs {
+		out = append(out, co.pending[seq])
+	}
+	clear(co.pending)
+	if co.timer != nil {
+		co.timer.Stop()
+		co.timer = nil
+	}
+	co.next = 1
+	return out
+}
+
+func (b *reorderBuffer) emitAll(ctx context.Context, ds []*Delivery) bool {
+	for _, d := range ds {
+		if !b.emit(ctx, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// collect takes the consecutive pending deliveries and rearms the timer;
+// the caller holds mu.
+func (b *reorderBuffer) collect(key string, co *connOrder) []*Delivery {
+	var ready []*Delivery
+	for {
+		d, ok := co.pending[co.next]
+		if !ok {
+			break
+		}
+		delete(co.pending, co.next)
+		ready = append(ready, d)
+		co.next++
+	}
+	if co.timer != nil {
+		co.timer.Stop()
+		co.timer = nil
+	}
+	if len(co.pending) > 0 {
+		co.timer = time.AfterFunc(b.timeout, func() { b.expire(key, co) })
+	}
+	return ready
+}
+
+// expire gives up on the missing frames of key and resumes at the lowest pending one.
+func (b *reorderBuffer) expire(key string, co *connOrder) {
+	co.emitMu.Lock()
+	defer co.emitMu.Unlock()
+	b.mu.Lock()
+	if b.conns[key] != co || len(co.pending) == 0 {
+		b.mu.Unlock()
+		return
+	}
+	lowest := uint64(0)
+	for seq := range co.pending {
+		if lowest == 0 || seq < lowest {
+			lowest = seq
+		}
+	}
+	if lowest <= co.next {
+		b.mu.Unlock()
+		return
+	}
+	gap := SequenceGap{ConnectionID: key, Expected: co.next, Received: lowest}
+	co.next = lowest
+	co.timer = nil
+	ready := b.collect(key, co)
+	b.mu.Unlock()
+	b.onGap(gap)
+	b.emitAll(b.ctx, ready)
+}
+
+// forget drops the state of a closed connection.
+func (b *reorderBuffer) forget(connectionID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if co := b.conns[connectionID]; co != nil && co.timer != nil {
+		co.timer.Stop()
+	}
+	delete(b.conns, connectionID)
+}
+
+// reset forgets every connection when the stream moves to another worker
+// instance, whose counters start over; frames still waiting for a gap are
+// released in order first.
+func (b *reorderBuffer) reset(ctx context.Context) {
+	b.mu.Lock()
+	conns := b.conns
+	b.conns = map[string]*connOrder{}
+	b.mu.Unlock()
+	for _, co := range conns {
+		co.emitMu.Lock()
+		b.mu.Lock()
+		flushed := co.restart()
+		b.mu.Unlock()
+		ok := b.emitAll(ctx, flushed)
+		co.emitMu.Unlock()
+		if !ok {
+			return
+		}
+	}
+}
+
+// sweep drops idle connections without pending frames; the caller holds mu.
+func (b *reorderBuffer) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < time.Minute {
+		return
+	}
+	b.lastSweep = now
+	for key, co := range b.conns {
+		if len(co.pending) == 0 && now.Sub(co.lastSeen) > reorderIdleTTL {
+			delete(b.conns, key)
+		}
+	}
+}
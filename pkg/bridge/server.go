@@ -6,54 +6,129 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
-	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
 )
 
+const defaultGracefulShutdownTimeout = 10 * time.Second
+
+// ErrServerClosed is returned when serving or registering after Close.
+var ErrServerClosed = errors.New("bridge server closed")
+
 // NewServer builds a gRPC server that wires stream events to Handler.
-func NewServer(opts Options) (Server, error) {
-	if opts.Address == "" {
-		return nil, errors.New("server address is required")
+// Options.Address is only required by Serve; Register and ServeListener
+// use the caller's gRPC server or listener.
+func NewServer(opts Options) (WorkerServer, error) {
+	if opts.MaxFrameSize <= 0 {
+		opts.MaxFrameSize = defaultMaxFrameSize
+	}
+	if opts.GracefulShutdownTimeout <= 0 {
+		opts.GracefulShutdownTimeout = defaultGracefulShutdownTimeout
+	}
+	if opts.ReplayBufferSize == 0 {
+		opts.ReplayBufferSize = defaultReplayBufferSize
+	}
+	if opts.ReplayBufferTTL <= 0 {
+		opts.ReplayBufferTTL = defaultReplayBufferTTL
+	}
+	capFrameSize(&opts)
+	codec, err := newFrameCodec(opts)
+	if err != nil {
+		return nil, err
 	}
-	return &server{opts: opts}, nil
+	var sequences *sequencer
+	if opts.OrderedDelivery {
+		sequences = newSequencer()
+	}
+	return &server{
+		opts:       opts,
+		codec:      codec,
+		health:     newHealthReporter(opts),
+		broadcasts: newBroadcastTracker(opts.BroadcastAckTimeout, opts.OnBroadcastResult),
+		sessions:   map[*session]struct{}{},
+		// The resume token identifies this server instance: sequence numbers
+		// restart with the process, so other tokens are never replayed.
+		resumeToken: uuid.NewString(),
+		replays:     map[string]*replayBuffer{},
+		sequences:   sequences,
+	}, nil
+}
+
+// GRPCServerOptions returns the gRPC server options Serve would use for opts
+// (TLS credentials, message size limits, keepalive policy and
+// Options.ServerOptions), for callers that build their own *grpc.Server
+// before calling Register.
+func GRPCServerOptions(opts Options) ([]grpc.ServerOption, error) {
+	return opts.grpcServerOptions(false)
 }
 
 type server struct {
 	opts       Options
+	codec      *frameCodec
+	health     *healthReporter
+	broadcasts *broadcastTracker
+	stopOnce   sync.Once
+
+	mu         sync.Mutex
 	grpcServer *grpc.Server
 	lis        net.Listener
-	stopOnce   sync.Once
+	registered bool
+	draining   bool
+	sessions   map[*session]struct{}
+	active     sync.WaitGroup
+
+	resumeToken string
+	replays     map[string]*replayBuffer
+	// sequences is nil unless Options.OrderedDelivery is set.
+	sequences *sequencer
 }
 
 func (s *server) Serve(ctx context.Context, handler Handler) error {
+	if s.opts.Address == "" {
+		return errors.New("server address is required")
+	}
 	if handler == nil {
 		return errors.New("handler is required")
 	}
-	lis, err := net.Listen("tcp", s.opts.Address)
+	lis, err := listen(s.opts.Address)
 	if err != nil {
 		return fmt.Errorf("listen %s: %w", s.opts.Address, err)
 	}
-	s.lis = lis
-	var serverOpts []grpc.ServerOption
-	if !s.opts.Insecure {
-		tlsConf := &tls.Config{}
-		if s.opts.TLSCertFile != "" && s.opts.TLSKeyFile != "" {
-			cert, loadErr := tls.LoadX509KeyPair(s.opts.TLSCertFile, s.opts.TLSKeyFile)
-			if loadErr != nil {
-				return loadErr
-			}
-			tlsConf.Certificates = []tls.Certificate{cert}
-		}
-		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	return s.ServeListener(ctx, lis, handler)
+}
+
+// ServeListener serves the bridge on a caller-supplied listener. The listener
+// is closed when ctx is done or Close is called.
+func (s *server) ServeListener(ctx context.Context, lis net.Listener, handler Handler) error {
+	if lis == nil {
+		return errors.New("listener is required")
+	}
+	_, inproc := lis.(*inprocListener)
+	serverOpts, err := s.opts.grpcServerOptions(inproc)
+	if err != nil {
+		_ = lis.Close()
+		return err
 	}
 	srv := grpc.NewServer(serverOpts...)
-	bridgepb.RegisterSidecarBridgeServer(srv, &bridgeService{handler: handler})
+	if err := s.Register(srv, handler); err != nil {
+		_ = lis.Close()
+		return err
+	}
+	s.mu.Lock()
 	s.grpcServer = srv
+	s.lis = lis
+	s.mu.Unlock()
 	go func() {
 		<-ctx.Done()
 		s.Close()
@@ -61,63 +136,165 @@ func (s *server) Serve(ctx context.Context, handler Handler) error {
 	return srv.Serve(lis)
 }
 
-func (s *server) Close() error {
-	s.stopOnce.Do(func() {
-		if s.grpcServer != nil {
-			s.grpcServer.GracefulStop()
-		}
-		if s.lis != nil {
-			_ = s.lis.Close()
-		}
-	})
+// Register attaches the SidecarBridge service to registrar, typically a
+// *grpc.Server shared with other services, together with the health service
+// (unless Options.HealthServer is set) and optional reflection. Close drains
+// bridge sessions but leaves the registrar running.
+func (s *server) Register(registrar grpc.ServiceRegistrar, handler Handler) error {
+	if registrar == nil {
+		return errors.New("service registrar is required")
+	}
+	if handler == nil {
+		return errors.New("handler is required")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return ErrServerClosed
+	}
+	if s.registered {
+		return errors.New("bridge service already registered")
+	}
+	s.registered = true
+	bridgepb.RegisterSidecarBridgeServer(registrar, &bridgeService{srv: s, handler: handler})
+	s.health.register(registrar, s.opts.EnableReflection)
+	s.health.start()
 	return nil
 }
 
-type bridgeService struct {
-	bridgepb.UnimplementedSidecarBridgeServer
-	handler Handler
+// AddHealthCheck registers a named dependency check. While any check fails the
+// bridge reports NOT_SERVING; a nil check removes the named check.
+func (s *server) AddHealthCheck(name string, check HealthCheck) {
+	s.health.add(name, check)
 }
 
-type session struct {
-	meta   RegisterMeta
-	stream bridgepb.SidecarBridge_StreamServer
-	sendMu sync.Mutex
-}
+// Close drains the server: it stops accepting streams, gracefully stops a
+// gRPC server created by Serve or ServeListener and lets active sessions end
+// on their own for up to GracefulShutdownTimeout. Sessions still open then
+// are ended and the gRPC server is stopped.
+func (s *server) Close() error {
+	s.stopOnce.Do(func() {
+		s.health.drain()
+		s.mu.Lock()
+		s.draining = true
+		grpcServer, lis := s.grpcServer, s.lis
+		s.mu.Unlock()
 
-func (s *session) SendDeliver(ctx context.Context, env envelope.TransportEnvelope) error {
-	envelope.NormalizeEnvelope(&env)
-	resp := &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Deliver{Deliver: &bridgepb.DeliverFrame{Envelope: &env}}}
-	return s.send(ctx, resp)
+		timeout := time.NewTimer(s.opts.GracefulShutdownTimeout)
+		defer timeout.Stop()
+		drained := make(chan struct{})
+		go func() {
+			if grpcServer != nil {
+				grpcServer.GracefulStop()
+			}
+			s.active.Wait()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+		case <-timeout.C:
+			s.mu.Lock()
+			for sess := range s.sessions {
+				sess.cancel()
+			}
+			s.mu.Unlock()
+			if grpcServer != nil {
+				grpcServer.Stop()
+			}
+			<-drained
+		}
+		if lis != nil {
+			_ = lis.Close()
+		}
+	})
+	return nil
 }
 
-func (s *session) SendBroadcast(ctx context.Context, env envelope.TransportEnvelope) error {
-	envelope.NormalizeEnvelope(&env)
-	resp := &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Broadcast{Broadcast: &bridgepb.BroadcastFrame{Envelope: &env}}}
-	return s.send(ctx, resp)
+// track registers a session unless the server is draining.
+func (s *server) track(sess *session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return status.Error(codes.Unavailable, "bridge server is draining")
+	}
+	s.sessions[sess] = struct{}{}
+	s.active.Add(1)
+	return nil
 }
 
-func (s *session) SendHeartbeat(ctx context.Context, nonce string) error {
-	resp := &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Heartbeat{Heartbeat: &bridgepb.HeartbeatFrame{Nonce: nonce}}}
-	return s.send(ctx, resp)
+func (s *server) untrack(sess *session) {
+	s.mu.Lock()
+	delete(s.sessions, sess)
+	s.mu.Unlock()
+	s.broadcasts.sessionClosed(sess.id, sess.replay != nil)
+	s.active.Done()
 }
 
-func (s *session) send(ctx context.Context, resp *bridgepb.StreamResponse) error {
-	s.sendMu.Lock()
-	defer s.sendMu.Unlock()
-	return s.stream.Send(resp)
+// resume attaches the node's replay buffer to sess, returns the resume token
+// and the last received ingress seq in the response header and resends the
+// frames the sidecar missed.
+func (s *server) resume(sess *session, reg *bridgepb.RegisterFrame) error {
+	buf := s.replayFor(sess.meta.NodeID)
+	if buf == nil {
+		return nil
+	}
+	sess.replay = buf
+	resumed := reg.GetResumeToken() == s.resumeToken
+	ingress := buf.resetIngress(resumed)
+	header := metadata.Pairs(resumeTokenHeader, s.resumeToken, ingressSeqHeader, strconv.FormatUint(ingress, 10))
+	if err := sess.stream.SendHeader(header); err != nil {
+		return err
+	}
+	if !resumed {
+		return nil
+	}
+	frames, lost := buf.since(reg.GetLastSeq())
+	if lost > 0 {
+		logger.WithTrace(sess.ctx).WithField("node_id", sess.meta.NodeID).WithField("lost", lost).Warn("bridge replay buffer overflowed, frames lost")
+	}
+	for _, resp := range frames {
+		if id := resp.GetBroadcast().GetBroadcastId(); id != "" {
+			s.broadcasts.resumed(id, sess)
+		}
+	}
+	return sess.resume(frames)
 }
 
-func (s *session) Metadata() RegisterMeta {
-	return s.meta
+func (o Options) grpcServerOptions(inproc bool) ([]grpc.ServerOption, error) {
+	var serverOpts []grpc.ServerOption
+	if !o.Insecure && !inproc {
+		tlsConf := &tls.Config{}
+		if o.TLSCertFile != "" && o.TLSKeyFile != "" {
+			cert, err := tls.LoadX509KeyPair(o.TLSCertFile, o.TLSKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConf.Certificates = []tls.Certificate{cert}
+		}
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+	return append(serverOpts, o.serverOptions()...), nil
 }
 
-func (s *session) Close() error {
-	return s.stream.Context().Err()
+type bridgeService struct {
+	bridgepb.UnimplementedSidecarBridgeServer
+	srv     *server
+	handler Handler
 }
 
 func (svc *bridgeService) Stream(stream bridgepb.SidecarBridge_StreamServer) error {
-	ctx := stream.Context()
-	first, err := stream.Recv()
+	opts := svc.srv.opts
+	// Answer in the client's encoding: frameCodec for clients with
+	// Compression set, plain protobuf otherwise.
+	var codec *frameCodec
+	if usesCodec(stream.Context()) {
+		codec = svc.srv.codec
+	}
+	recv := func() (*bridgepb.StreamRequest, error) {
+		req := &bridgepb.StreamRequest{}
+		return req, codec.recv(stream, req)
+	}
+	first, err := recv()
 	if err != nil {
 		return err
 	}
@@ -130,27 +307,96 @@ func (svc *bridgeService) Stream(stream bridgepb.SidecarBridge_StreamServer) err
 		Namespace: reg.Namespace,
 		Version:   reg.BridgeVersion,
 	}
-	sess := &session{meta: meta, stream: stream}
+	sess := newSession(stream, meta, opts, svc.srv.broadcasts, svc.srv.sequences)
+	sess.codec = codec
+	defer sess.cancel()
+	ctx := sess.ctx
+	opts.Recorder.recordRequest(RecordSideServer, meta.NodeID, sess.id, first)
+	defer svc.srv.releaseReplay(sess)
+	if err := svc.srv.resume(sess, reg); err != nil {
+		return err
+	}
+	if err := svc.srv.track(sess); err != nil {
+		return err
+	}
+	defer svc.srv.untrack(sess)
+	presence := svc.srv.presenceUpdater(sess)
+	defer presence.close()
 	if err := svc.handler.OnRegister(ctx, sess, meta); err != nil {
 		return err
 	}
 	defer svc.handler.OnClose(ctx, sess)
+
+	// Receive in the background so draining can end the stream between frames.
+	frames := make(chan *bridgepb.StreamRequest)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case frames <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	chunks := newChunkAssembler(opts.MaxChunkedSize, opts.ChunkTimeout)
 	for {
-		req, err := stream.Recv()
-		if err != nil {
+		var req *bridgepb.StreamRequest
+		select {
+		case <-ctx.Done():
+			if stream.Context().Err() != nil {
+				return stream.Context().Err()
+			}
+			return status.Error(codes.Unavailable, "bridge session closed by server")
+		case err := <-recvErr:
 			return err
+		case req = <-frames:
 		}
+		if chunk := req.GetChunk(); chunk != nil {
+			req = &bridgepb.StreamRequest{}
+			done, err := chunks.frame(chunk, req)
+			if err != nil {
+				logger.WithTrace(ctx).WithError(err).WithField("node_id", meta.NodeID).Warn("bridge frame lost, resetting stream")
+				return status.Error(codes.DataLoss, err.Error())
+			}
+			if !done {
+				continue
+			}
+		}
+		opts.Recorder.recordRequest(RecordSideServer, meta.NodeID, sess.id, req)
 		switch payload := req.GetPayload().(type) {
 		case *bridgepb.StreamRequest_Ingress:
+			if sess.replay != nil && !sess.replay.acceptIngress(req.GetSeq()) {
+				// Resent by a resuming sidecar, already handled.
+				continue
+			}
 			if payload.Ingress != nil && payload.Ingress.Envelope != nil {
-				if err := svc.handler.OnIngress(ctx, sess, *payload.Ingress.Envelope); err != nil {
+				err := svc.handler.OnIngress(ctx, sess, *payload.Ingress.Envelope)
+				if err := svc.handleError(ctx, sess, payload.Ingress.Envelope, err); err != nil {
 					return err
 				}
 			}
 		case *bridgepb.StreamRequest_Ack:
 			if payload.Ack != nil {
-				ack := Ack{MessageID: payload.Ack.MessageId, BroadcastID: payload.Ack.BroadcastId}
-				if err := svc.handler.OnAck(ctx, sess, ack); err != nil {
+				ack := Ack{
+					MessageID:   payload.Ack.MessageId,
+					BroadcastID: payload.Ack.BroadcastId,
+					Status:      payload.Ack.Status,
+					Reason:      payload.Ack.Reason,
+				}
+				if ack.Status == "" {
+					ack.Status = AckStatusOK
+				}
+				if ack.BroadcastID != "" {
+					svc.srv.broadcasts.ack(sess.id, ack)
+				}
+				if err := svc.handleError(ctx, sess, nil, svc.handler.OnAck(ctx, sess, ack)); err != nil {
 					return err
 				}
 			}
@@ -159,9 +405,28 @@ func (svc *bridgeService) Stream(stream bridgepb.SidecarBridge_StreamServer) err
 			if payload.Heartbeat != nil {
 				nonce = payload.Heartbeat.Nonce
 			}
-			if err := svc.handler.OnHeartbeat(ctx, sess, nonce); err != nil {
+			presence.touch()
+			if err := svc.handleError(ctx, sess, nil, svc.handler.OnHeartbeat(ctx, sess, nonce)); err != nil {
 				return err
 			}
+		case *bridgepb.StreamRequest_ConnectionEvent:
+			if payload.ConnectionEvent == nil {
+				continue
+			}
+			event := connectionEventFromFrame(payload.ConnectionEvent)
+			presence.track(event)
+			if event.Type == ConnectionClosed {
+				svc.srv.sequences.forget(event.ConnectionID)
+			}
+			if h, ok := svc.handler.(ConnectionEventHandler); ok {
+				if err := svc.handleError(ctx, sess, nil, h.OnConnectionEvent(ctx, sess, event)); err != nil {
+					return err
+				}
+			}
+		case *bridgepb.StreamRequest_ControlResult:
+			if payload.ControlResult != nil {
+				sess.resolveControl(payload.ControlResult)
+			}
 		case *bridgepb.StreamRequest_Register:
 			// duplicate register ignored
 		}
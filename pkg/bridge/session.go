@@ -0,0 +1,196 @@
+package bridge
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/peer"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+type session struct {
+	id          string
+	meta        RegisterMeta
+	remoteAddr  net.Addr
+	connectedAt time.Time
+	stream      bridgepb.SidecarBridge_StreamServer
+	// codec encodes frames on streams opened with the bridge content-subtype; nil otherwise.
+	codec        *frameCodec
+	ctx          context.Context
+	cancel       context.CancelFunc
+	sendMu       sync.Mutex
+	maxFrameSize int
+
+	controlMu      sync.Mutex
+	controls       map[string]chan ControlResult
+	controlTimeout time.Duration
+
+	broadcasts *broadcastTracker
+	sequences  *sequencer
+	// replay numbers and retains Deliver/Broadcast frames; nil when resumption is disabled.
+	replay *replayBuffer
+	// recorder is Options.Recorder; nil when recording is off.
+	recorder *Recorder
+
+	attrMu sync.RWMutex
+	attrs  map[string]any
+}
+
+func newSession(stream bridgepb.SidecarBridge_StreamServer, meta RegisterMeta, opts Options, broadcasts *broadcastTracker, sequences *sequencer) *session {
+	ctx, cancel := context.WithCancel(stream.Context())
+	sess := &session{
+		id:           uuid.NewString(),
+		meta:         meta,
+		connectedAt:  time.Now(),
+		stream:       stream,
+		ctx:          ctx,
+		cancel:       cancel,
+		maxFrameSize: opts.MaxFrameSize,
+		attrs:        map[string]any{},
+
+		controls:       map[string]chan ControlResult{},
+		controlTimeout: opts.ControlTimeout,
+		broadcasts:     broadcasts,
+		sequences:      sequences,
+		recorder:       opts.Recorder,
+	}
+	if sess.controlTimeout <= 0 {
+		sess.controlTimeout = defaultControlTimeout
+	}
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		sess.remoteAddr = p.Addr
+	}
+	return sess
+}
+
+func (s *session) SendDeliver(ctx context.Context, env envelope.TransportEnvelope) error {
+	envelope.NormalizeEnvelope(&env)
+	resp := &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Deliver{Deliver: &bridgepb.DeliverFrame{Envelope: &env}}}
+	return s.send(ctx, resp)
+}
+
+// SendBroadcast stamps a broadcast_id (AttrBroadcastID or a uuid) and tracks
+// the sidecar's ack; the outcome is reported to Options.OnBroadcastResult.
+// Sending the same AttrBroadcastID to several sessions aggregates their acks
+// into one result while it is pending; a session sent the id after the
+// broadcast settled gets the frame untracked. Use Server.Broadcast to
+// register every session up front.
+func (s *session) SendBroadcast(ctx context.Context, env envelope.TransportEnvelope) error {
+	envelope.NormalizeEnvelope(&env)
+	id := env.GetAttributes()[AttrBroadcastID]
+	if id == "" {
+		id = uuid.NewString()
+	}
+	s.broadcasts.start(id, []*session{s}, 0)
+	resp := &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Broadcast{Broadcast: &bridgepb.BroadcastFrame{Envelope: &env, BroadcastId: id}}}
+	if err := s.send(ctx, resp); err != nil {
+		s.broadcasts.settle(id, s.id, true, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (s *session) SendHeartbeat(ctx context.Context, nonce string) error {
+	resp := &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Heartbeat{Heartbeat: &bridgepb.HeartbeatFrame{Nonce: nonce}}}
+	return s.send(ctx, resp)
+}
+
+// send writes a frame, splitting it into ChunkFrames when it exceeds MaxFrameSize.
+// Deliver and Broadcast frames are numbered and retained for resumption.
+// Deliver frames get their nfc_sequence here, in write order; a frame that
+// is neither written nor retained gives its number back.
+func (s *session) send(ctx context.Context, resp *bridgepb.StreamResponse) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	env := resp.GetDeliver().GetEnvelope()
+	stamped := env != nil && s.sequences.stamp(env)
+	if s.replay != nil && replayable(resp) {
+		// Retained frames are resent on resume, keeping their number.
+		return s.write(s.replay.record(resp))
+	}
+	err := s.write(resp)
+	if err != nil && stamped {
+		s.sequences.release(env)
+	}
+	return err
+}
+
+// resume resends frames buffered for a previous stream of the same node.
+func (s *session) resume(frames []*bridgepb.StreamResponse) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	for _, resp := range frames {
+		if err := s.write(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write sends resp; the caller holds sendMu.
+func (s *session) write(resp *bridgepb.StreamResponse) error {
+	s.recorder.recordResponse(RecordSideServer, s.meta.NodeID, s.id, resp)
+	chunks, err := splitFrame(resp, s.maxFrameSize)
+	if err != nil {
+		return err
+	}
+	if chunks == nil {
+		return s.codec.send(s.stream, resp)
+	}
+	for _, chunk := range chunks {
+		if err := s.codec.send(s.stream, &bridgepb.StreamResponse{Payload: &bridgepb.StreamResponse_Chunk{Chunk: chunk}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *session) Metadata() RegisterMeta {
+	return s.meta
+}
+
+func (s *session) ID() string {
+	return s.id
+}
+
+func (s *session) RemoteAddr() net.Addr {
+	return s.remoteAddr
+}
+
+func (s *session) ConnectedAt() time.Time {
+	return s.connectedAt
+}
+
+func (s *session) Context() context.Context {
+	return s.ctx
+}
+
+func (s *session) Set(key string, value any) {
+	s.attrMu.Lock()
+	s.attrs[key] = value
+	s.attrMu.Unlock()
+}
+
+func (s *session) Get(key string) (any, bool) {
+	s.attrMu.RLock()
+	defer s.attrMu.RUnlock()
+	value, ok := s.attrs[key]
+	return value, ok
+}
+
+func (s *session) Delete(key string) {
+	s.attrMu.Lock()
+	delete(s.attrs, key)
+	s.attrMu.Unlock()
+}
+
+// Close ends the stream; the sidecar sees Unavailable and reconnects.
+func (s *session) Close() error {
+	s.cancel()
+	return nil
+}
@@ -0,0 +1,218 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
+)
+
+const defaultAckTimeout = 30 * time.Second
+
+// ErrClientClosed indicates the client was closed.
+var ErrClientClosed = errors.New("bridge client closed")
+
+// AckMode selects how handler-based consumers acknowledge deliveries.
+type AckMode int
+
+const (
+	// AckAuto acks after the handler returns nil and nacks when it returns an error.
+	AckAuto AckMode = iota
+	// AckManual leaves Ack/Nack to the handler.
+	AckManual
+)
+
+// DeliverFunc handles one Deliver frame.
+type DeliverFunc func(ctx context.Context, d *Delivery) error
+
+// BroadcastFunc handles one Broadcast frame.
+type BroadcastFunc func(ctx context.Context, d *BroadcastDelivery) error
+
+// HandleOptions configure HandleDeliver and HandleBroadcast.
+type HandleOptions struct {
+	// Concurrency is the number of goroutines invoking the handler (default 1).
+	Concurrency int
+	// AckMode defaults to AckAuto.
+	AckMode AckMode
+	// AckTimeout logs a warning for deliveries still unacknowledged after it
+	// (default 30s, negative disables).
+	AckTimeout time.Duration
+	// HandlerTimeout bounds each handler call through its ctx (0 = no limit).
+	HandlerTimeout time.Duration
+}
+
+// ackable is implemented by Delivery and BroadcastDelivery.
+type ackable interface {
+	Ack(ctx context.Context) error
+	Nack(ctx context.Context, reason string) error
+	ref() string
+	isSettled() bool
+}
+
+func (s *settlement) isSettled() bool {
+	return s.settled.Load()
+}
+
+// subscribe forwards queued deliveries to a per-call channel until ctx is done
+// or the client closes; the returned channel is closed then. Concurrent
+// subscribers compete for deliveries, each delivery is received once.
+func subscribe[T ackable](c *client, ctx context.Context, queue chan T, consumers *atomic.Int32) (<-chan T, error) {
+	if c.closed.Load() {
+		return nil, ErrClientClosed
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out := make(chan T)
+	consumers.Add(1)
+	go func() {
+		defer close(out)
+		defer consumers.Add(-1)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.ctx.Done():
+				return
+			case d := <-queue:
+				select {
+				case out <- d:
+				case <-ctx.Done():
+					requeue(queue, d)
+					return
+				case <-c.ctx.Done():
+					_ = d.Nack(context.Background(), "client closed")
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// requeue hands d back to the queue, nacking it when the queue is full.
+func requeue[T ackable](queue chan T, d T) {
+	select {
+	case queue <- d:
+	default:
+		_ = d.Nack(context.Background(), "subscriber gone")
+	}
+}
+
+// handle starts opts.Concurrency workers invoking fn for queued deliveries.
+func handle[T ackable](c *client, queue chan T, consumers *atomic.Int32, opts HandleOptions, fn func(context.Context, T) error) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.AckTimeout == 0 {
+		opts.AckTimeout = defaultAckTimeout
+	}
+	for i := 0; i < opts.Concurrency; i++ {
+		consumers.Add(1)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer consumers.Add(-1)
+			for {
+				select {
+				case <-c.ctx.Done():
+					return
+				case d := <-queue:
+					invoke(c.ctx, opts, fn, d)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+func invoke[T ackable](ctx context.Context, opts HandleOptions, fn func(context.Context, T) error, d T) {
+	if opts.HandlerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.HandlerTimeout)
+		defer cancel()
+	}
+	var timer *time.Timer
+	if opts.AckTimeout > 0 {
+		timer = time.AfterFunc(opts.AckTimeout, func() {
+			if !d.isSettled() {
+				logger.WithField("ref", d.ref()).WithField("timeout", opts.AckTimeout).Warn("bridge delivery not acknowledged in time")
+			}
+		})
+	}
+	err := safeCall(ctx, fn, d)
+	if opts.AckMode != AckAuto {
+		return
+	}
+	// Acks must go out even when the client is shutting down.
+	ackCtx := context.WithoutCancel(ctx)
+	if err != nil {
+		_ = d.Nack(ackCtx, err.Error())
+	} else {
+		_ = d.Ack(ackCtx)
+	}
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+func safeCall[T any](ctx context.Context, fn func(context.Context, T) error, d T) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("bridge handler panic: %v", r)
+		}
+	}()
+	return fn(ctx, d)
+}
+
+func (c *client) SubscribeDeliver(ctx context.Context) (<-chan *Delivery, error) {
+	return subscribe(c, ctx, c.deliverCh, &c.deliverConsumers)
+}
+
+func (c *client) SubscribeBroadcast(ctx context.Context) (<-chan *BroadcastDelivery, error) {
+	return subscribe(c, ctx, c.broadcastCh, &c.broadcastConsumers)
+}
+
+// HandleDeliver consumes Deliver frames with fn until the client is closed.
+func (c *client) HandleDeliver(fn DeliverFunc, opts HandleOptions) error {
+	if fn == nil {
+		return errors.New("deliver handler is required")
+	}
+	return handle(c, c.deliverCh, &c.deliverConsumers, opts, fn)
+}
+
+// HandleBroadcast consumes Broadcast frames with fn until the client is closed.
+func (c *client) HandleBroadcast(fn BroadcastFunc, opts HandleOptions) error {
+	if fn == nil {
+		return errors.New("broadcast handler is required")
+	}
+	return handle(c, c.broadcastCh, &c.broadcastConsumers, opts, fn)
+}
+
+// enqueue hands a delivery to consumers. Without any consumer it only buffers
+// up to the queue size and nacks beyond that, so the receive loop never
+// blocks on an unread queue. It returns false once ctx is done.
+func enqueue[T ackable](ctx context.Context, queue chan T, consumers *atomic.Int32, d T) bool {
+	select {
+	case queue <- d:
+		return true
+	default:
+	}
+	if consumers.Load() == 0 {
+		logger.WithField("ref", d.ref()).Warn("bridge delivery nacked: no consumer")
+		_ = d.Nack(ctx, "no consumer")
+		return true
+	}
+	select {
+	case queue <- d:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+package bridge_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+// TestHandleDeliverAckAutoSaturated echoes every ingress frame back as a
+// Deliver from the worker's receive loop while several publishers keep the
+// client stream full. Auto acks must not wait behind the publishers holding
+// the send lock, or client and worker block on each other.
+func TestHandleDeliverAckAutoSaturated(t *testing.T) {
+	const (
+		publishers = 8
+		perPub     = 200
+		payload    = 64 << 10
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	addr := fmt.Sprintf("inproc://bridge-ack-auto-%d", benchAddrSeq.Add(1))
+	opts := bridge.Options{Address: addr, Insecure: true, NodeID: "ack", Namespace: "ack", DeliverBuffer: 1}
+	srv, err := bridge.NewServer(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	w := &benchWorker{sessions: make(chan bridge.Session, 1), onIngress: echoIngress}
+	go func() { _ = srv.Serve(ctx, w) }()
+
+	client, err := bridge.NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	delivered := make(chan struct{}, publishers*perPub)
+	err = client.HandleDeliver(func(ctx context.Context, d *bridge.Delivery) error {
+		delivered <- struct{}{}
+		return nil
+	}, bridge.HandleOptions{AckTimeout: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := waitStarted(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+
+	text := strings.Repeat("x", payload)
+	var wg sync.WaitGroup
+	errs := make(chan error, publishers)
+	for p := range publishers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perPub {
+				err := client.PublishIngress(ctx, envelope.TransportEnvelope{
+					ConnectionId: benchConnID,
+					Message: &envelope.Message{
+						Action:    "bench.echo",
+						RequestId: fmt.Sprintf("r-%d-%d", p, i),
+						Payload:   &envelope.Payload{Text: &envelope.TextPayload{Content: text}},
+					},
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for range publishers * perPub {
+		select {
+		case <-delivered:
+		case err := <-errs:
+			t.Fatalf("publish: %v", err)
+		case <-ctx.Done():
+			t.Fatal("client and worker deadlocked with auto acks on a saturated stream")
+		}
+	}
+	wg.Wait()
+}
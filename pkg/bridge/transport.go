@@ -0,0 +1,156 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Address schemes understood by Options.Address besides plain host:port.
+const (
+	// SchemeUnix selects a Unix domain socket, e.g. "unix:///var/run/bridge.sock".
+	SchemeUnix = "unix"
+	// SchemeInProcess selects the in-process transport, e.g. "inproc://worker".
+	// Client and server must live in the same process; no sockets are used.
+	SchemeInProcess = "inproc"
+)
+
+// ErrNoInProcessServer indicates no server is serving the in-process address.
+var ErrNoInProcessServer = errors.New("no in-process bridge server")
+
+// staleSocketDialTimeout bounds the probe of an existing unix socket.
+const staleSocketDialTimeout = time.Second
+
+var inprocListeners = struct {
+	mu sync.Mutex
+	m  map[string]*inprocListener
+}{m: map[string]*inprocListener{}}
+
+// splitAddress returns the scheme ("" for TCP) and the scheme-specific address.
+func splitAddress(addr string) (scheme, target string) {
+	switch {
+	case strings.HasPrefix(addr, SchemeUnix+"://"):
+		return SchemeUnix, strings.TrimPrefix(addr, SchemeUnix+"://")
+	case strings.HasPrefix(addr, SchemeUnix+":"):
+		return SchemeUnix, strings.TrimPrefix(addr, SchemeUnix+":")
+	case strings.HasPrefix(addr, SchemeInProcess+"://"):
+		return SchemeInProcess, strings.TrimPrefix(addr, SchemeInProcess+"://")
+	default:
+		return "", addr
+	}
+}
+
+// listen opens the listener for a server address.
+func listen(addr string) (net.Listener, error) {
+	scheme, target := splitAddress(addr)
+	switch scheme {
+	case SchemeUnix:
+		if err := removeStaleSocket(target); err != nil {
+			return nil, err
+		}
+		return net.Listen("unix", target)
+	case SchemeInProcess:
+		return listenInProcess(target)
+	default:
+		return net.Listen("tcp", target)
+	}
+}
+
+// removeStaleSocket removes a socket left behind by a previous process. A
+// socket something still answers on is left alone.
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+	conn, err := net.DialTimeout("unix", path, staleSocketDialTimeout)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("unix socket %s is in use", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func listenInProcess(name string) (net.Listener, error) {
+	if name == "" {
+		return nil, errors.New("in-process address name is required")
+	}
+	inprocListeners.mu.Lock()
+	defer inprocListeners.mu.Unlock()
+	if _, exists := inprocListeners.m[name]; exists {
+		return nil, fmt.Errorf("in-process address %q already in use", name)
+	}
+	lis := &inprocListener{name: name, conns: make(chan net.Conn), done: make(chan struct{})}
+	inprocListeners.m[name] = lis
+	return lis, nil
+}
+
+// inprocListener hands out the server ends of net.Pipe pairs created by
+// dialInProcess; it unregisters itself on Close.
+type inprocListener struct {
+	name  string
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (l *inprocListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *inprocListener) Close() error {
+	l.once.Do(func() {
+		inprocListeners.mu.Lock()
+		if inprocListeners.m[l.name] == l {
+			delete(inprocListeners.m, l.name)
+		}
+		inprocListeners.mu.Unlock()
+		close(l.done)
+	})
+	return nil
+}
+
+func (l *inprocListener) Addr() net.Addr {
+	return inprocAddr(l.name)
+}
+
+// inprocAddr is the net.Addr of an in-process listener.
+type inprocAddr string
+
+func (a inprocAddr) Network() string { return SchemeInProcess }
+func (a inprocAddr) String() string  { return string(a) }
+
+func dialInProcess(ctx context.Context, name string) (net.Conn, error) {
+	inprocListeners.mu.Lock()
+	lis := inprocListeners.m[name]
+	inprocListeners.mu.Unlock()
+	if lis == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoInProcessServer, name)
+	}
+	client, server := net.Pipe()
+	select {
+	case lis.conns <- server:
+		return client, nil
+	case <-lis.done:
+		_ = client.Close()
+		_ = server.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNoInProcessServer, name)
+	case <-ctx.Done():
+		_ = client.Close()
+		_ = server.Close()
+		return nil, ctx.Err()
+	}
+}
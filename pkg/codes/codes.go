@@ -1,5 +1,7 @@
 package codes
 
+import "errors"
+
 // ErrorCode represents structured transport errors shared across services.
 type ErrorCode struct {
 	Numeric int32
@@ -28,3 +30,75 @@ var Registry = []ErrorCode{
 	ErrTooManyRequests,
 	ErrInternal,
 }
+
+// Error implements the error interface so codes can be returned directly.
+func (e ErrorCode) Error() string {
+	return e.Symbol + ": " + e.Message
+}
+
+// Error pairs an ErrorCode with the underlying cause.
+type Error struct {
+	Code  ErrorCode
+	Cause error
+}
+
+// Wrap annotates cause with code. A nil cause returns code itself.
+func Wrap(code ErrorCode, cause error) error {
+	if cause == nil {
+		return code
+	}
+	return &Error{Code: code, Cause: cause}
+}
+
+func (e *Error) Error() string {
+	return e.Code.Error() + ": " + e.Cause.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is the wrapped ErrorCode.
+func (e *Error) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	return ok && code == e.Code
+}
+
+// FromError extracts the ErrorCode carried by err, defaulting to ErrInternal.
+func FromError(err error) ErrorCode {
+	var wrapped *Error
+	if errors.As(err, &wrapped) {
+		return wrapped.Code
+	}
+	var code ErrorCode
+	if errors.As(err, &code) {
+		return code
+	}
+	return ErrInternal
+}
+
+// WithDetails attaches details meant for clients to err. Error replies copy
+// only these details; the rest of err stays on the server.
+func WithDetails(err error, details string) error {
+	if err == nil {
+		return nil
+	}
+	return &detailedError{err: err, details: details}
+}
+
+// Details returns the client details attached to err with WithDetails.
+func Details(err error) string {
+	var d *detailedError
+	if errors.As(err, &d) {
+		return d.details
+	}
+	return ""
+}
+
+type detailedError struct {
+	err     error
+	details string
+}
+
+func (e *detailedError) Error() string { return e.err.Error() }
+func (e *detailedError) Unwrap() error { return e.err }
@@ -22,6 +22,30 @@ func (s *SlotConfig) ApplyDefaults() {
 	if s.MaxRetry <= 0 {
 		s.MaxRetry = 3
 	}
+	if s.KeySource == "" {
+		s.KeySource = "user_id"
+	}
+	if s.MigrationTimeoutSeconds <= 0 {
+		s.MigrationTimeoutSeconds = 30
+	}
+	if s.ReplayBufferSize <= 0 {
+		s.ReplayBufferSize = 1024
+	}
+}
+
+// ==================== PresenceConfig 默认值 ====================
+
+// ApplyDefaults 应用 Presence 配置默认值
+func (p *PresenceConfig) ApplyDefaults() {
+	if p.RedisPrefix == "" {
+		p.RedisPrefix = "gga"
+	}
+	if p.TTLSeconds <= 0 {
+		p.TTLSeconds = 90
+	}
+	if p.RefreshIntervalSeconds <= 0 {
+		p.RefreshIntervalSeconds = 30
+	}
 }
 
 // ==================== MetricsConfig 默认值 ====================
@@ -52,6 +76,11 @@ func (b *BridgeClientConfig) ApplyDefaults() {
 	if b.PendingAckTimeoutSeconds <= 0 {
 		b.PendingAckTimeoutSeconds = 15
 	}
+	if b.CompressionMinBytes <= 0 {
+		b.CompressionMinBytes = 1024
+	}
+	// Keepalive 不设默认值：Worker 以 Register 挂载且未使用 GRPCServerOptions 时，
+	// gRPC 服务端默认只允许 5 分钟一次 ping，客户端 30s ping 会被 too_many_pings 断开
 }
 
 // ==================== BridgeServerConfig 默认值 ====================
@@ -73,6 +102,22 @@ func (b *BridgeServerConfig) ApplyDefaults() {
 	if b.PendingAckTimeoutSeconds <= 0 {
 		b.PendingAckTimeoutSeconds = 15
 	}
+	if b.CompressionMinBytes <= 0 {
+		b.CompressionMinBytes = 1024
+	}
+	if b.KeepaliveMinTimeSeconds <= 0 {
+		b.KeepaliveMinTimeSeconds = 10
+	}
+	if b.HealthCheckIntervalSeconds <= 0 {
+		b.HealthCheckIntervalSeconds = 10
+	}
+	// 负数表示关闭断线重放，仅 0 取默认值
+	if b.ReplayBufferSize == 0 {
+		b.ReplayBufferSize = 1024
+	}
+	if b.ReplayBufferTTLSeconds <= 0 {
+		b.ReplayBufferTTLSeconds = 300
+	}
 }
 
 // ==================== TracingConfig 默认值 ====================
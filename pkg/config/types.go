@@ -61,13 +61,25 @@ type JWTConfig struct {
 
 // SlotConfig Slot 路由配置
 type SlotConfig struct {
-	Enabled              bool   `yaml:"enabled" mapstructure:"enabled"`
-	TotalSlots           int    `yaml:"total_slots" mapstructure:"total_slots"`
-	RedisPrefix          string `yaml:"redis_prefix" mapstructure:"redis_prefix"`
-	LeaseTTLSeconds      int    `yaml:"lease_ttl_seconds" mapstructure:"lease_ttl_seconds"`
-	RouteTTLSeconds      int    `yaml:"route_ttl_seconds" mapstructure:"route_ttl_seconds"`           // SideCar 使用
-	RenewIntervalSeconds int    `yaml:"renew_interval_seconds" mapstructure:"renew_interval_seconds"` // Worker 使用
-	MaxRetry             int    `yaml:"max_retry" mapstructure:"max_retry"`
+	Enabled                 bool   `yaml:"enabled" mapstructure:"enabled"`
+	TotalSlots              int    `yaml:"total_slots" mapstructure:"total_slots"` // 最大 65536，建议取 2 的幂以避免取模偏斜
+	RedisPrefix             string `yaml:"redis_prefix" mapstructure:"redis_prefix"`
+	LeaseTTLSeconds         int    `yaml:"lease_ttl_seconds" mapstructure:"lease_ttl_seconds"`
+	RouteTTLSeconds         int    `yaml:"route_ttl_seconds" mapstructure:"route_ttl_seconds"`           // SideCar 使用
+	RenewIntervalSeconds    int    `yaml:"renew_interval_seconds" mapstructure:"renew_interval_seconds"` // Worker 使用
+	MaxRetry                int    `yaml:"max_retry" mapstructure:"max_retry"`
+	KeySource               string `yaml:"key_source" mapstructure:"key_source"`                               // user_id | conversation_id | attr:<name>
+	MigrationTimeoutSeconds int    `yaml:"migration_timeout_seconds" mapstructure:"migration_timeout_seconds"` // Worker 等待新 owner 接管的最长时间
+	ReplayBufferSize        int    `yaml:"replay_buffer_size" mapstructure:"replay_buffer_size"`               // SideCar 迁移期间每个 slot 暂存的 ingress 上限
+}
+
+// ==================== Presence 配置 (SideCar & Worker 共用) ====================
+
+// PresenceConfig 在线状态目录配置（user_id → node_id/connection_id）
+type PresenceConfig struct {
+	RedisPrefix            string `yaml:"redis_prefix" mapstructure:"redis_prefix"`
+	TTLSeconds             int    `yaml:"ttl_seconds" mapstructure:"ttl_seconds"`                           // 连接记录过期时间，需大于刷新间隔
+	RefreshIntervalSeconds int    `yaml:"refresh_interval_seconds" mapstructure:"refresh_interval_seconds"` // 节点心跳刷新间隔
 }
 
 // ==================== Bridge 配置 ====================
@@ -84,21 +96,40 @@ type BridgeClientConfig struct {
 	EnableBackpressure       bool              `yaml:"enable_backpressure" mapstructure:"enable_backpressure"`
 	MaxInFlightDeliver       int               `yaml:"max_inflight_deliver" mapstructure:"max_inflight_deliver"`
 	PendingAckTimeoutSeconds int               `yaml:"pending_ack_timeout_seconds" mapstructure:"pending_ack_timeout_seconds"`
+	Compression              string            `yaml:"compression" mapstructure:"compression"` // "" | gzip | zstd
+	CompressionMinBytes      int               `yaml:"compression_min_bytes" mapstructure:"compression_min_bytes"`
+	MaxSendMsgBytes          int               `yaml:"max_send_msg_bytes" mapstructure:"max_send_msg_bytes"`         // 0 = gRPC 默认
+	MaxRecvMsgBytes          int               `yaml:"max_recv_msg_bytes" mapstructure:"max_recv_msg_bytes"`         // 0 = gRPC 默认 (4MB)
+	KeepaliveTimeSeconds     int               `yaml:"keepalive_time_seconds" mapstructure:"keepalive_time_seconds"` // 0 = 不发 ping，需不小于服务端 keepalive_min_time_seconds
+	KeepaliveTimeoutSeconds  int               `yaml:"keepalive_timeout_seconds" mapstructure:"keepalive_timeout_seconds"`
+	KeepaliveWithoutStream   bool              `yaml:"keepalive_without_stream" mapstructure:"keepalive_without_stream"`
 }
 
 // BridgeServerConfig gRPC Bridge 服务端配置 (Worker 使用)
 type BridgeServerConfig struct {
-	ListenAddr               string   `yaml:"listen_addr" mapstructure:"listen_addr"`
-	Namespace                string   `yaml:"namespace" mapstructure:"namespace"`
-	Actions                  []string `yaml:"actions" mapstructure:"actions"`
-	TLSCertFile              string   `yaml:"tls_cert_file" mapstructure:"tls_cert_file"`
-	TLSKeyFile               string   `yaml:"tls_key_file" mapstructure:"tls_key_file"`
-	DeliverBuffer            int      `yaml:"deliver_buffer" mapstructure:"deliver_buffer"`
-	HeartbeatIntervalSeconds int      `yaml:"heartbeat_interval_seconds" mapstructure:"heartbeat_interval_seconds"`
-	ReconnectInitialSeconds  int      `yaml:"reconnect_initial_seconds" mapstructure:"reconnect_initial_seconds"`
-	ReconnectMaxSeconds      int      `yaml:"reconnect_max_seconds" mapstructure:"reconnect_max_seconds"`
-	PendingAckTimeoutSeconds int      `yaml:"pending_ack_timeout_seconds" mapstructure:"pending_ack_timeout_seconds"`
-	MaxInFlightDeliver       int      `yaml:"max_inflight_deliver" mapstructure:"max_inflight_deliver"`
+	ListenAddr                 string   `yaml:"listen_addr" mapstructure:"listen_addr"`
+	Namespace                  string   `yaml:"namespace" mapstructure:"namespace"`
+	Actions                    []string `yaml:"actions" mapstructure:"actions"`
+	TLSCertFile                string   `yaml:"tls_cert_file" mapstructure:"tls_cert_file"`
+	TLSKeyFile                 string   `yaml:"tls_key_file" mapstructure:"tls_key_file"`
+	DeliverBuffer              int      `yaml:"deliver_buffer" mapstructure:"deliver_buffer"`
+	HeartbeatIntervalSeconds   int      `yaml:"heartbeat_interval_seconds" mapstructure:"heartbeat_interval_seconds"`
+	ReconnectInitialSeconds    int      `yaml:"reconnect_initial_seconds" mapstructure:"reconnect_initial_seconds"`
+	ReconnectMaxSeconds        int      `yaml:"reconnect_max_seconds" mapstructure:"reconnect_max_seconds"`
+	PendingAckTimeoutSeconds   int      `yaml:"pending_ack_timeout_seconds" mapstructure:"pending_ack_timeout_seconds"`
+	MaxInFlightDeliver         int      `yaml:"max_inflight_deliver" mapstructure:"max_inflight_deliver"`
+	Compression                string   `yaml:"compression" mapstructure:"compression"` // "" | gzip | zstd
+	CompressionMinBytes        int      `yaml:"compression_min_bytes" mapstructure:"compression_min_bytes"`
+	MaxSendMsgBytes            int      `yaml:"max_send_msg_bytes" mapstructure:"max_send_msg_bytes"` // 0 = gRPC 默认
+	MaxRecvMsgBytes            int      `yaml:"max_recv_msg_bytes" mapstructure:"max_recv_msg_bytes"` // 0 = gRPC 默认 (4MB)
+	KeepaliveTimeSeconds       int      `yaml:"keepalive_time_seconds" mapstructure:"keepalive_time_seconds"`
+	KeepaliveTimeoutSeconds    int      `yaml:"keepalive_timeout_seconds" mapstructure:"keepalive_timeout_seconds"`
+	KeepaliveMinTimeSeconds    int      `yaml:"keepalive_min_time_seconds" mapstructure:"keepalive_min_time_seconds"` // 允许客户端 ping 的最小间隔
+	KeepaliveWithoutStream     bool     `yaml:"keepalive_without_stream" mapstructure:"keepalive_without_stream"`
+	HealthCheckIntervalSeconds int      `yaml:"health_check_interval_seconds" mapstructure:"health_check_interval_seconds"` // 依赖健康检查间隔
+	EnableReflection           bool     `yaml:"enable_reflection" mapstructure:"enable_reflection"`                         // 调试用，生产环境建议关闭
+	ReplayBufferSize           int      `yaml:"replay_buffer_size" mapstructure:"replay_buffer_size"`                       // 每个 Sidecar 节点保留的可重放帧数，负数关闭
+	ReplayBufferTTLSeconds     int      `yaml:"replay_buffer_ttl_seconds" mapstructure:"replay_buffer_ttl_seconds"`         // 节点最后一个 stream 断开后保留重放缓冲的时长
 }
 
 // ==================== 可观测性配置 ====================
@@ -22,6 +22,8 @@ type TextPayload = bridgepb.TextPayload
 
 type AudioPayload = bridgepb.AudioPayload
 
+type BinaryPayload = bridgepb.BinaryPayload
+
 type ErrorPayload = bridgepb.ErrorPayload
 
 type TransportEnvelope = bridgepb.TransportEnvelope
@@ -57,7 +59,7 @@ func ValidateIngress(msg *bridgepb.Message) error {
 		return errors.New("action is required")
 	}
 	payload := msg.GetPayload()
-	if payload == nil || (payload.GetText() == nil && payload.GetAudio() == nil) {
+	if payload == nil || (payload.GetText() == nil && payload.GetAudio() == nil && payload.GetBinary() == nil) {
 		return errors.New("payload is required")
 	}
 	return nil
@@ -0,0 +1,148 @@
+package envelope
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	bridgepb "github.com/Goden-Gun/transport-lib/gen/go/bridge/v1"
+)
+
+// Slot key sources understood by SlotKeyFromSpec.
+const (
+	SlotKeyUserID         = "user_id"
+	SlotKeyConversationID = "conversation_id"
+	SlotKeyAttrPrefix     = "attr:"
+)
+
+// MaxTotalSlots is the largest useful slot count: the CRC16 checksum has
+// 65536 values, so slots beyond it are never assigned.
+const MaxTotalSlots = 1 << 16
+
+// SlotKeyFunc derives the routing key used to compute a slot for an envelope.
+// An empty key means the envelope carries no routable identity.
+type SlotKeyFunc func(env *bridgepb.TransportEnvelope) string
+
+// ComputeSlot maps key onto [0, totalSlots) using CRC16-CCITT (XMODEM), the
+// same checksum Redis Cluster uses for key slots. If the key contains a
+// non-empty "{...}" hash tag only the tag is hashed, so related keys can be
+// pinned to one slot. The result is stable across processes and releases.
+//
+// totalSlots above MaxTotalSlots only ever yield slots below MaxTotalSlots.
+// Counts that do not divide 65536 favour lower slots slightly (modulo bias);
+// a power of two spreads keys evenly.
+func ComputeSlot(key string, totalSlots int) uint32 {
+	if totalSlots <= 0 {
+		return 0
+	}
+	return uint32(crc16(hashTag(key))) % uint32(totalSlots)
+}
+
+// SlotKeyByUser keys envelopes by user_id.
+func SlotKeyByUser(env *bridgepb.TransportEnvelope) string {
+	if env == nil || env.GetUserId() == 0 {
+		return ""
+	}
+	return strconv.FormatInt(env.GetUserId(), 10)
+}
+
+// SlotKeyByConversation keys envelopes by message.conversation_id.
+func SlotKeyByConversation(env *bridgepb.TransportEnvelope) string {
+	if env == nil {
+		return ""
+	}
+	return env.GetMessage().GetConversationId()
+}
+
+// SlotKeyByAttribute keys envelopes by attributes[name].
+func SlotKeyByAttribute(name string) SlotKeyFunc {
+	return func(env *bridgepb.TransportEnvelope) string {
+		if env == nil {
+			return ""
+		}
+		return env.GetAttributes()[name]
+	}
+}
+
+// SlotKeyFromSpec resolves a configured key source: "user_id" (default),
+// "conversation_id" or "attr:<name>". Other specs are rejected.
+func SlotKeyFromSpec(spec string) (SlotKeyFunc, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "" || spec == SlotKeyUserID:
+		return SlotKeyByUser, nil
+	case spec == SlotKeyConversationID:
+		return SlotKeyByConversation, nil
+	case strings.HasPrefix(spec, SlotKeyAttrPrefix):
+		name := strings.TrimPrefix(spec, SlotKeyAttrPrefix)
+		if name == "" {
+			return nil, fmt.Errorf("slot key source %q: empty attribute name", spec)
+		}
+		return SlotKeyByAttribute(name), nil
+	default:
+		return nil, fmt.Errorf("unknown slot key source %q", spec)
+	}
+}
+
+// EnvelopeSlot computes the slot for env using keyFn.
+// ok is false when the envelope yields no key.
+func EnvelopeSlot(env *bridgepb.TransportEnvelope, keyFn SlotKeyFunc, totalSlots int) (slotID uint32, ok bool) {
+	if env == nil || totalSlots <= 0 {
+		return 0, false
+	}
+	if keyFn == nil {
+		keyFn = SlotKeyByUser
+	}
+	key := keyFn(env)
+	if key == "" {
+		return 0, false
+	}
+	return ComputeSlot(key, totalSlots), true
+}
+
+// StampSlot computes the slot for env and records it via SetSlot.
+// Envelopes without a key are left untouched.
+func StampSlot(env *bridgepb.TransportEnvelope, keyFn SlotKeyFunc, totalSlots int, generation uint32) (uint32, bool) {
+	slotID, ok := EnvelopeSlot(env, keyFn, totalSlots)
+	if !ok {
+		return 0, false
+	}
+	SetSlot(env, slotID, generation)
+	return slotID, true
+}
+
+func hashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+var crc16Table = func() [256]uint16 {
+	var table [256]uint16
+	for i := range table {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+func crc16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^key[i]]
+	}
+	return crc
+}
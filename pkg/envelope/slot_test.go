@@ -0,0 +1,92 @@
+package envelope_test
+
+import (
+	"testing"
+
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+// Expected slots are fixed: changing them reroutes every deployed key.
+func TestComputeSlot(t *testing.T) {
+	cases := []struct {
+		key   string
+		total int
+		want  uint32
+	}{
+		// CRC16-XMODEM check value and raw checksums (65536 slots).
+		{"123456789", envelope.MaxTotalSlots, 0x31C3},
+		{"", envelope.MaxTotalSlots, 0},
+		{"foo", envelope.MaxTotalSlots, 44950},
+		{"foo", envelope.MaxTotalSlots * 4, 44950},
+		// Redis Cluster CLUSTER KEYSLOT values (16384 slots).
+		{"foo", 16384, 12182},
+		{"somekey", 16384, 11058},
+		{"hello", 16384, 866},
+		// Default 512 slots, keyed by user_id.
+		{"42", 512, 320},
+		// Hash tags: only the first non-empty {...} is hashed.
+		{"user1000", 16384, 3443},
+		{"{user1000}.following", 16384, 3443},
+		{"{user1000}.followers", 16384, 3443},
+		{"a{b}c{d}", 16384, 3300},
+		{"foo{{bar}}zap", 16384, 4015}, // tag is "{bar"
+		{"{bar}}zap", 16384, 5061},     // tag is "bar"
+		// Empty or unclosed tags hash the whole key.
+		{"foo{}{bar}", 16384, 8363},
+		{"{}", 16384, 15257},
+		{"foo{", 16384, 7673},
+		{"foo{bar", 16384, 15278},
+		// No slots.
+		{"foo", 0, 0},
+		{"foo", -1, 0},
+	}
+	for _, tc := range cases {
+		if got := envelope.ComputeSlot(tc.key, tc.total); got != tc.want {
+			t.Errorf("ComputeSlot(%q, %d) = %d, want %d", tc.key, tc.total, got, tc.want)
+		}
+	}
+}
+
+func TestSlotKeyFromSpec(t *testing.T) {
+	env := &envelope.TransportEnvelope{
+		UserId:     42,
+		Attributes: map[string]string{"room": "r-1"},
+		Message:    &envelope.Message{ConversationId: "c-9"},
+	}
+	cases := []struct {
+		spec string
+		want string
+	}{
+		{"", "42"},
+		{"user_id", "42"},
+		{" user_id ", "42"},
+		{"conversation_id", "c-9"},
+		{"attr:room", "r-1"},
+		{"attr:missing", ""},
+	}
+	for _, tc := range cases {
+		keyFn, err := envelope.SlotKeyFromSpec(tc.spec)
+		if err != nil {
+			t.Fatalf("SlotKeyFromSpec(%q): %v", tc.spec, err)
+		}
+		if got := keyFn(env); got != tc.want {
+			t.Errorf("SlotKeyFromSpec(%q) key = %q, want %q", tc.spec, got, tc.want)
+		}
+	}
+	for _, spec := range []string{"attr:", "device_id", "User_ID", "attr"} {
+		if _, err := envelope.SlotKeyFromSpec(spec); err == nil {
+			t.Errorf("SlotKeyFromSpec(%q) accepted", spec)
+		}
+	}
+}
+
+func TestStampSlot(t *testing.T) {
+	env := &envelope.TransportEnvelope{UserId: 42}
+	slotID, ok := envelope.StampSlot(env, nil, 512, 3)
+	if !ok || slotID != 320 || env.GetSlotId() != 320 || env.GetSlotGeneration() != 3 {
+		t.Fatalf("StampSlot = %d, %t; envelope slot %d gen %d", slotID, ok, env.GetSlotId(), env.GetSlotGeneration())
+	}
+	if _, ok := envelope.StampSlot(&envelope.TransportEnvelope{}, nil, 512, 3); ok {
+		t.Fatal("envelope without user_id was stamped")
+	}
+}
@@ -0,0 +1,84 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Goden-Gun/transport-lib/pkg/auth"
+)
+
+// ErrMissingToken is returned by the built-in authenticators when the request carries no token.
+var ErrMissingToken = errors.New("access token is required")
+
+// Identity is the authenticated principal of a client connection.
+type Identity struct {
+	UserID int64
+	// Sequence is the device/login sequence carried by the token.
+	Sequence string
+	// Attributes are copied into the connection's ConnectionOpened event.
+	Attributes map[string]string
+}
+
+// Authenticator authenticates a connection request (WebSocket upgrade or
+// HTTP fallback) before any frame is accepted.
+type Authenticator interface {
+	Authenticate(r *http.Request) (Identity, error)
+}
+
+// AuthenticatorFunc adapts a function to Authenticator.
+type AuthenticatorFunc func(r *http.Request) (Identity, error)
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (Identity, error) {
+	return f(r)
+}
+
+// BearerToken returns the token from the Authorization header, falling back
+// to the access_token query parameter (browsers cannot set headers on
+// WebSocket upgrades).
+func BearerToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		if scheme, token, ok := strings.Cut(h, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return r.URL.Query().Get("access_token")
+}
+
+// JWTAuthenticator verifies access tokens issued by auth.GenerateTokenPairWithVersion.
+type JWTAuthenticator struct {
+	Config auth.Config
+	// Blocklist and Versions are optional; see auth.VerifyAccessTokenWithVersion.
+	Blocklist auth.AccessTokenBlocklist
+	Versions  auth.SessionVersionStore
+}
+
+func (a JWTAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	token := BearerToken(r)
+	if token == "" {
+		return Identity{}, ErrMissingToken
+	}
+	claims, err := auth.VerifyAccessTokenWithVersion(token, a.Config, a.Blocklist, a.Versions)
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{UserID: claims.UserID, Sequence: claims.Sequence}, nil
+}
+
+// SimpleTokenAuthenticator verifies tokens issued by auth.GenerateSimpleToken.
+type SimpleTokenAuthenticator struct {
+	Config auth.SimpleTokenConfig
+	Store  auth.TokenVersionStore
+}
+
+func (a SimpleTokenAuthenticator) Authenticate(r *http.Request) (Identity, error) {
+	token := BearerToken(r)
+	if token == "" {
+		return Identity{}, ErrMissingToken
+	}
+	claims, err := auth.VerifySimpleToken(r.Context(), token, a.Config, a.Store)
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{UserID: claims.UserID, Sequence: claims.Sequence}, nil
+}
@@ -0,0 +1,239 @@
+package gateway
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrConnClosed is reported for frames queued on a closed connection.
+	ErrConnClosed = errors.New("gateway connection closed")
+	// ErrSlowConsumer is reported when a connection's send queue is full; the
+	// connection is closed.
+	ErrSlowConsumer = errors.New("gateway connection send queue full")
+)
+
+// Close reasons reported in ConnectionClosed events.
+const (
+	reasonClientClosed = "client closed"
+	reasonShutdown     = "gateway shutting down"
+	reasonSlowConsumer = "slow consumer"
+)
+
+// outbound is one encoded frame for a client; done, when set, is called
+// once it was written or dropped.
+type outbound struct {
+	data []byte
+	done func(error)
+}
+
+func (f outbound) finish(err error) {
+	if f.done != nil {
+		f.done(err)
+	}
+}
+
+// transport carries frames to one client (WebSocket or HTTP fallback).
+type transport interface {
+	// send queues f without blocking; it fails with ErrSlowConsumer or ErrConnClosed.
+	send(f outbound) error
+	// close ends the connection; kind selects the close status shown to the client.
+	close(kind closeKind, reason string)
+}
+
+type closeKind int
+
+const (
+	closeNormal    closeKind = iota
+	closePolicy              // kicked or closed by the worker
+	closeGoingAway           // gateway shutdown
+	closeTryAgain            // slow consumer
+)
+
+// Conn is one authenticated client connection.
+type Conn struct {
+	id       string
+	identity Identity
+	remote   string
+	openedAt time.Time
+	t        transport
+
+	limiter atomic.Pointer[limiter]
+
+	closeOnce   sync.Once
+	closeReason atomic.Value // string
+}
+
+func newConn(id string, identity Identity, remote string, t transport) *Conn {
+	return &Conn{id: id, identity: identity, remote: remote, openedAt: time.Now(), t: t}
+}
+
+// ID is the connection id stamped on ingress and used by Deliver targets.
+func (c *Conn) ID() string { return c.id }
+
+// UserID is the authenticated user.
+func (c *Conn) UserID() int64 { return c.identity.UserID }
+
+// Identity returns the identity the connection authenticated with.
+func (c *Conn) Identity() Identity { return c.identity }
+
+// RemoteAddr is the client's address as seen by the HTTP server.
+func (c *Conn) RemoteAddr() string { return c.remote }
+
+// OpenedAt is when the connection was accepted.
+func (c *Conn) OpenedAt() time.Time { return c.openedAt }
+
+func (c *Conn) send(f outbound) error {
+	err := c.t.send(f)
+	if errors.Is(err, ErrSlowConsumer) {
+		c.close(closeTryAgain, reasonSlowConsumer)
+	}
+	return err
+}
+
+// close ends the connection once; the first reason wins.
+func (c *Conn) close(kind closeKind, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeReason.Store(reason)
+		c.t.close(kind, reason)
+	})
+}
+
+// reason returns why the gateway closed the connection, or fallback when it did not.
+func (c *Conn) reason(fallback string) string {
+	if r, ok := c.closeReason.Load().(string); ok {
+		return r
+	}
+	return fallback
+}
+
+// allow applies the connection's throttle, if any, dropping it once expired.
+func (c *Conn) allow() bool {
+	l := c.limiter.Load()
+	if l == nil {
+		return true
+	}
+	now := time.Now()
+	if l.expired(now) {
+		c.limiter.CompareAndSwap(l, nil)
+		return true
+	}
+	return l.allow(now)
+}
+
+// registry indexes open connections by id and user.
+type registry struct {
+	mu    sync.RWMutex
+	conns map[string]*Conn
+	users map[int64]map[string]*Conn
+}
+
+func newRegistry() *registry {
+	return &registry{conns: map[string]*Conn{}, users: map[int64]map[string]*Conn{}}
+}
+
+func (r *registry) add(c *Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conns[c.id] = c
+	byID := r.users[c.UserID()]
+	if byID == nil {
+		byID = map[string]*Conn{}
+		r.users[c.UserID()] = byID
+	}
+	byID[c.id] = c
+}
+
+func (r *registry) remove(c *Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conns[c.id] != c {
+		return
+	}
+	delete(r.conns, c.id)
+	if byID := r.users[c.UserID()]; byID != nil {
+		delete(byID, c.id)
+		if len(byID) == 0 {
+			delete(r.users, c.UserID())
+		}
+	}
+}
+
+func (r *registry) get(id string) (*Conn, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.conns[id]
+	return c, ok
+}
+
+// lookup returns the open connections among ids and of userIDs, without duplicates.
+func (r *registry) lookup(ids []string, userIDs []int64) []*Conn {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := map[string]bool{}
+	var out []*Conn
+	for _, id := range ids {
+		if c, ok := r.conns[id]; ok && !seen[id] {
+			seen[id] = true
+			out = append(out, c)
+		}
+	}
+	for _, uid := range userIDs {
+		for id, c := range r.users[uid] {
+			if !seen[id] {
+				seen[id] = true
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+func (r *registry) all() []*Conn {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*Conn, 0, len(r.conns))
+	for _, c := range r.conns {
+		out = append(out, c)
+	}
+	return out
+}
+
+func (r *registry) len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.conns)
+}
+
+// limiter is a token bucket set by ControlThrottle.
+type limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+	until time.Time // zero when the throttle does not expire
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	return &limiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+func (l *limiter) expired(now time.Time) bool {
+	return !l.until.IsZero() && !now.Before(l.until)
+}
+
+func (l *limiter) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
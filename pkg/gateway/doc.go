@@ -0,0 +1,35 @@
+// Package gateway is a ready-made sidecar: it accepts WebSocket connections
+// from end clients, authenticates them with pkg/auth, and bridges them to a
+// worker through a bridge.Client.
+//
+// Each client frame is a protojson envelope.Message. The gateway validates
+// it with envelope.ValidateIngress, wraps it in a TransportEnvelope stamped
+// with the connection, user and node ids, normalises it and publishes it as
+// ingress; rejected frames are answered with an error Message on the same
+// socket. Deliver and Broadcast frames from the worker are routed to the
+// targeted sockets and acked once written (nacked when no socket took
+// them). Control commands kick connections (ControlKick, ControlCloseUser)
+// or rate-limit their ingress (ControlThrottle with the bridge.ThrottleParam*
+// params).
+//
+// Clients that cannot keep a WebSocket open use the HTTP fallback instead:
+// ServeEvents streams the same frames as Server-Sent Events, resumable with
+// Last-Event-ID, and ServeIngress accepts one frame per POST. Both share the
+// connection registry, so the worker cannot tell the transports apart.
+//
+// Example:
+//
+//	client, _ := bridge.NewClient(bridge.Options{Address: "worker:50051", NodeID: nodeID, Namespace: "chat"})
+//	gw, _ := gateway.New(client, gateway.Options{
+//		Authenticator: gateway.JWTAuthenticator{Config: auth.Config{Secret: secret}},
+//		NodeID:        nodeID,
+//		Namespace:     "chat",
+//	})
+//	_ = client.Start(ctx)
+//	http.HandleFunc("/ws", gw.ServeWebSocket)
+//	http.HandleFunc("/events", gw.ServeEvents)
+//	http.HandleFunc("/ingress", gw.ServeIngress)
+//	// on shutdown
+//	_ = gw.Close(shutdownCtx)
+//	_ = client.Close()
+package gateway
@@ -0,0 +1,403 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/Goden-Gun/transport-lib/pkg/bridge"
+	"github.com/Goden-Gun/transport-lib/pkg/codes"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
+)
+
+const (
+	defaultSendBuffer         = 256
+	defaultMaxMessageBytes    = 1 << 20
+	defaultWriteTimeout       = 10 * time.Second
+	defaultPingInterval       = 30 * time.Second
+	defaultDeliverConcurrency = 4
+)
+
+// ErrGatewayClosed is returned for connections attempted after Close.
+var ErrGatewayClosed = errors.New("gateway closed")
+
+// Options configure a Gateway.
+type Options struct {
+	// Authenticator is required; see JWTAuthenticator and SimpleTokenAuthenticator.
+	Authenticator Authenticator
+	// NodeID and Namespace are stamped on every ingress envelope.
+	NodeID    string
+	Namespace string
+	// CheckOrigin validates the WebSocket Origin header; nil allows same-origin requests only.
+	CheckOrigin func(r *http.Request) bool
+	// SendBuffer is the per-connection queue of outgoing frames (default 256);
+	// a connection whose queue is full is closed as a slow consumer.
+	SendBuffer int
+	// MaxMessageBytes limits a client frame (default 1 MiB).
+	MaxMessageBytes int64
+	// WriteTimeout bounds each socket write (default 10s).
+	WriteTimeout time.Duration
+	// PingInterval is the keepalive period (default 30s): WebSocket pings,
+	// of which a client missing two is disconnected, and SSE comments.
+	PingInterval time.Duration
+	// ResumeWindow is how long an SSE connection outlives its event stream
+	// waiting for a Last-Event-ID reconnect (default 30s).
+	ResumeWindow time.Duration
+	// DeliverConcurrency is the number of goroutines routing Deliver and
+	// Broadcast frames each (default 4).
+	DeliverConcurrency int
+}
+
+func (o *Options) applyDefaults() {
+	if o.SendBuffer <= 0 {
+		o.SendBuffer = defaultSendBuffer
+	}
+	if o.MaxMessageBytes <= 0 {
+		o.MaxMessageBytes = defaultMaxMessageBytes
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = defaultWriteTimeout
+	}
+	if o.PingInterval <= 0 {
+		o.PingInterval = defaultPingInterval
+	}
+	if o.ResumeWindow <= 0 {
+		o.ResumeWindow = defaultResumeWindow
+	}
+	if o.DeliverConcurrency <= 0 {
+		o.DeliverConcurrency = defaultDeliverConcurrency
+	}
+}
+
+// Gateway is the sidecar side of the bridge: it terminates client
+// connections, publishes their messages as ingress and routes Deliver and
+// Broadcast frames back to them.
+type Gateway struct {
+	client bridge.Client
+	opts   Options
+	conns  *registry
+
+	// mu orders connection registration against Close: once closed is
+	// set no connection is added, so Close's sweep and wait see them all.
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// New creates a Gateway on client and registers its Deliver, Broadcast and
+// Control handlers; the client must not have other consumers for them.
+// Start the client separately.
+func New(client bridge.Client, opts Options) (*Gateway, error) {
+	if client == nil {
+		return nil, errors.New("bridge client is required")
+	}
+	if opts.Authenticator == nil {
+		return nil, errors.New("authenticator is required")
+	}
+	opts.applyDefaults()
+	g := &Gateway{client: client, opts: opts, conns: newRegistry()}
+	handleOpts := bridge.HandleOptions{Concurrency: opts.DeliverConcurrency, AckMode: bridge.AckManual}
+	err := errors.Join(
+		client.HandleDeliver(g.onDeliver, handleOpts),
+		client.HandleBroadcast(g.onBroadcast, handleOpts),
+		client.HandleControl(g.onControl, bridge.HandleOptions{}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Connections returns the number of open client connections.
+func (g *Gateway) Connections() int {
+	return g.conns.len()
+}
+
+// Close stops accepting connections and closes the open ones, waiting
+// until their ConnectionClosed events were published or ctx is done. The
+// bridge client is left running.
+func (g *Gateway) Close(ctx context.Context) error {
+	g.mu.Lock()
+	g.closed = true
+	conns := g.conns.all()
+	g.mu.Unlock()
+	for _, c := range conns {
+		c.close(closeGoingAway, reasonShutdown)
+	}
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// authenticate runs the Authenticator, answering 401 (or 503 after Close) itself on failure.
+func (g *Gateway) authenticate(w http.ResponseWriter, r *http.Request) (Identity, bool) {
+	if g.isClosed() {
+		writeError(w, http.StatusServiceUnavailable, codes.Wrap(codes.ErrInternal, ErrGatewayClosed))
+		return Identity{}, false
+	}
+	identity, err := g.opts.Authenticator.Authenticate(r)
+	if err != nil {
+		logger.WithError(err).WithField("remote_addr", r.RemoteAddr).Info("gateway: authentication failed")
+		writeError(w, http.StatusUnauthorized, codes.Wrap(codes.ErrUnauthorized, err))
+		return Identity{}, false
+	}
+	return identity, true
+}
+
+func (g *Gateway) isClosed() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.closed
+}
+
+// open registers a new connection and reports it to the worker. Callers
+// must call disconnect when the connection ends. It returns
+// ErrGatewayClosed once Close has started.
+func (g *Gateway) open(ctx context.Context, identity Identity, remote string, t transport) (*Conn, error) {
+	c := newConn(uuid.NewString(), identity, remote, t)
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return nil, ErrGatewayClosed
+	}
+	g.wg.Add(1)
+	g.conns.add(c)
+	g.mu.Unlock()
+	err := g.client.PublishConnectionEvent(ctx, bridge.ConnectionEvent{
+		Type: bridge.ConnectionOpened, ConnectionID: c.id, UserID: identity.UserID, Attributes: identity.Attributes,
+	})
+	if err != nil {
+		logger.WithTrace(ctx).WithError(err).WithField("connection_id", c.id).Warn("gateway: publish connection opened")
+	}
+	return c, nil
+}
+
+// disconnect unregisters c and reports the close to the worker.
+func (g *Gateway) disconnect(c *Conn, fallbackReason string) {
+	defer g.wg.Done()
+	g.conns.remove(c)
+	reason := c.reason(fallbackReason)
+	c.close(closeNormal, reason)
+	err := g.client.PublishConnectionEvent(context.Background(), bridge.ConnectionEvent{
+		Type: bridge.ConnectionClosed, ConnectionID: c.id, UserID: c.UserID(), Reason: reason,
+	})
+	if err != nil {
+		logger.WithError(err).WithField("connection_id", c.id).Warn("gateway: publish connection closed")
+	}
+}
+
+// ingress decodes a client frame into an envelope stamped with c's ids and
+// publishes it. On failure it returns the error reply for the client.
+func (g *Gateway) ingress(ctx context.Context, c *Conn, data []byte) *envelope.Message {
+	env := &envelope.TransportEnvelope{
+		ConnectionId: c.id,
+		UserId:       c.UserID(),
+		NodeId:       g.opts.NodeID,
+		Namespace:    g.opts.Namespace,
+	}
+	msg := &envelope.Message{}
+	if err := unmarshalMessage(data, msg); err != nil {
+		return errorMessage(env, codes.Wrap(codes.ErrInvalidPayload, err))
+	}
+	env.Message = msg
+	if !c.allow() {
+		return errorMessage(env, codes.ErrTooManyRequests)
+	}
+	if err := envelope.ValidateIngress(msg); err != nil {
+		return errorMessage(env, codes.WithDetails(codes.Wrap(codes.ErrInvalidPayload, err), err.Error()))
+	}
+	envelope.NormalizeEnvelope(env)
+	if err := g.client.PublishIngress(ctx, *env); err != nil {
+		logger.WithTrace(ctx).WithError(err).WithField("connection_id", c.id).Warn("gateway: publish ingress")
+		return errorMessage(env, err)
+	}
+	return nil
+}
+
+func errorMessage(src *envelope.TransportEnvelope, err error) *envelope.Message {
+	return bridge.ErrorReply(src, err).GetMessage()
+}
+
+func (g *Gateway) onDeliver(ctx context.Context, d *bridge.Delivery) error {
+	env := d.Envelope
+	targets := g.conns.lookup(env.GetTargetConnectionIds(), env.GetTargetUserIds())
+	if len(env.GetTargetConnectionIds()) == 0 && len(env.GetTargetUserIds()) == 0 {
+		// Unicast: the envelope's own connection, or every connection of its user.
+		if env.GetConnectionId() != "" {
+			targets = g.conns.lookup([]string{env.GetConnectionId()}, nil)
+		} else if env.GetUserId() != 0 {
+			targets = g.conns.lookup(nil, []int64{env.GetUserId()})
+		}
+	}
+	if len(targets) == 0 {
+		return d.Nack(ctx, "no local connection")
+	}
+	g.fanout(env, targets, d.Ack, d.Nack)
+	return nil
+}
+
+func (g *Gateway) onBroadcast(ctx context.Context, d *bridge.BroadcastDelivery) error {
+	env := d.Envelope
+	var targets []*Conn
+	if len(env.GetTargetConnectionIds()) > 0 || len(env.GetTargetUserIds()) > 0 {
+		targets = g.conns.lookup(env.GetTargetConnectionIds(), env.GetTargetUserIds())
+	} else {
+		targets = g.conns.all()
+	}
+	if len(targets) == 0 {
+		return d.Ack(ctx)
+	}
+	g.fanout(env, targets, d.Ack, d.Nack)
+	return nil
+}
+
+// fanout writes env's message to targets and settles the frame once every
+// write finished: ack when at least one connection received it, nack with
+// the first error otherwise.
+func (g *Gateway) fanout(env *envelope.TransportEnvelope, targets []*Conn, ack func(context.Context) error, nack func(context.Context, string) error) {
+	ctx := context.Background()
+	data, err := marshalMessage(env.GetMessage())
+	if err != nil {
+		_ = nack(ctx, err.Error())
+		return
+	}
+	var (
+		mu        sync.Mutex
+		remaining = len(targets)
+		delivered int
+		firstErr  error
+	)
+	done := func(err error) {
+		mu.Lock()
+		remaining--
+		if err == nil {
+			delivered++
+		} else if firstErr == nil {
+			firstErr = err
+		}
+		last := remaining == 0
+		mu.Unlock()
+		if !last {
+			return
+		}
+		if delivered > 0 {
+			_ = ack(ctx)
+		} else {
+			_ = nack(ctx, firstErr.Error())
+		}
+	}
+	for _, c := range targets {
+		f := outbound{data: data, done: done}
+		if err := c.send(f); err != nil {
+			f.finish(err)
+		}
+	}
+}
+
+func (g *Gateway) onControl(ctx context.Context, ctl bridge.Control) (int, error) {
+	targets := g.conns.lookup(ctl.ConnectionIDs, ctl.UserIDs)
+	switch ctl.Command {
+	case bridge.ControlKick, bridge.ControlCloseUser:
+		reason := ctl.Reason
+		if reason == "" {
+			reason = "closed by worker"
+		}
+		for _, c := range targets {
+			c.close(closePolicy, reason)
+		}
+		return len(targets), nil
+	case bridge.ControlThrottle:
+		l, err := throttleFromParams(ctl.Params, time.Now())
+		if err != nil {
+			return 0, err
+		}
+		for _, c := range targets {
+			c.limiter.Store(l)
+		}
+		return len(targets), nil
+	}
+	return 0, fmt.Errorf("unsupported control command %s", ctl.Command)
+}
+
+// throttleFromParams reads ControlThrottle params: bridge.ThrottleParamRate
+// messages per second (<= 0 lifts the limit), optional ThrottleParamBurst
+// (default the rate rounded up) and ThrottleParamDuration after which the
+// limit expires. Missing rate and unknown keys are errors, so the worker
+// never gets a success result for a throttle that was not applied. Each
+// targeted connection gets its own bucket.
+func throttleFromParams(params map[string]string, now time.Time) (*limiter, error) {
+	for key := range params {
+		switch key {
+		case bridge.ThrottleParamRate, bridge.ThrottleParamBurst, bridge.ThrottleParamDuration:
+		default:
+			return nil, fmt.Errorf("unknown throttle param %q", key)
+		}
+	}
+	raw, ok := params[bridge.ThrottleParamRate]
+	if !ok {
+		return nil, fmt.Errorf("throttle param %q is required", bridge.ThrottleParamRate)
+	}
+	rate, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("throttle %s: %w", bridge.ThrottleParamRate, err)
+	}
+	if rate <= 0 {
+		return nil, nil
+	}
+	burst := int(math.Ceil(rate))
+	if raw := params[bridge.ThrottleParamBurst]; raw != "" {
+		if burst, err = strconv.Atoi(raw); err != nil || burst < 1 {
+			return nil, fmt.Errorf("throttle %s must be a positive integer, got %q", bridge.ThrottleParamBurst, raw)
+		}
+	}
+	l := newLimiter(rate, burst)
+	if raw := params[bridge.ThrottleParamDuration]; raw != "" {
+		ms, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || ms < 1 {
+			return nil, fmt.Errorf("throttle %s must be a positive integer, got %q", bridge.ThrottleParamDuration, raw)
+		}
+		l.until = now.Add(time.Duration(ms) * time.Millisecond)
+	}
+	return l, nil
+}
+
+// marshalMessage encodes a client frame: protojson with proto field names,
+// matching schema/transport-envelope.json.
+func marshalMessage(msg *envelope.Message) ([]byte, error) {
+	return protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+}
+
+// unmarshalMessage decodes a client frame; both proto and JSON field names
+// are accepted and unknown fields are ignored.
+func unmarshalMessage(data []byte, msg *envelope.Message) error {
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+}
+
+// writeError answers a rejected HTTP request with an error Message body.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeMessage(w, status, errorMessage(&envelope.TransportEnvelope{}, err))
+}
+
+func writeMessage(w http.ResponseWriter, status int, msg *envelope.Message) {
+	body, _ := marshalMessage(msg)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
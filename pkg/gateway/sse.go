@@ -0,0 +1,395 @@
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/codes"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+const (
+	defaultResumeWindow = 30 * time.Second
+
+	// ConnectionIDHeader names the connection a POST to ServeIngress is sent
+	// on; the connection_id query parameter is accepted as well.
+	ConnectionIDHeader = "X-Connection-Id"
+
+	reasonResumeExpired = "event stream not resumed"
+	reasonResumeGap     = "event stream resumed past the replay buffer"
+)
+
+var errUnknownConnection = errors.New("unknown connection")
+
+// ServeEvents streams a connection's frames as Server-Sent Events, for
+// clients that cannot keep a WebSocket open. It pairs with ServeIngress for
+// the client → gateway direction.
+//
+// The stream starts with an "open" event whose data is
+// {"connection_id": "..."}; every frame is then a "message" event with a
+// protojson Message as data and id "<connection_id>:<resume_key>:<seq>".
+// The resume key is random per connection and only sent on its event
+// stream, unlike the connection id POSTs carry. A request carrying such an
+// id in Last-Event-ID (or the last_event_id query parameter) resumes the
+// same connection, replaying the frames after it, as long as it comes
+// within ResumeWindow and the frames are still buffered; otherwise a new
+// connection is opened. When the gateway closes
+// the connection it sends a "close" event with {"code", "reason"} (the
+// WebSocket close code) and ends the stream; clients must not reconnect
+// with the old id after it.
+func (g *Gateway) ServeEvents(w http.ResponseWriter, r *http.Request) {
+	identity, ok := g.authenticate(w, r)
+	if !ok {
+		return
+	}
+	c, t, cursor := g.resumeEvents(identity, lastEventID(r))
+	if c == nil {
+		t = newSSETransport(g.opts.SendBuffer)
+		var err error
+		c, err = g.open(context.WithoutCancel(r.Context()), identity, r.RemoteAddr, t)
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, codes.Wrap(codes.ErrInternal, err))
+			return
+		}
+		t.onIdle = func() { c.close(closeNormal, reasonResumeExpired) }
+		go func() {
+			<-t.done
+			g.disconnect(c, reasonClientClosed)
+		}()
+	}
+	gen := t.attach()
+	defer t.detach(gen, g.opts.ResumeWindow)
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	s := &sseWriter{w: w, rc: http.NewResponseController(w), timeout: g.opts.WriteTimeout, idPrefix: c.id + ":" + t.resumeKey}
+	open := fmt.Sprintf(`{"connection_id":%q}`, c.id)
+	if err := s.event(cursor, "open", []byte(open)); err != nil {
+		return
+	}
+	t.stream(r.Context(), s, gen, cursor, g.opts.PingInterval)
+}
+
+// resumeEvents finds the SSE connection a Last-Event-ID refers to. It
+// returns a nil Conn when the id is absent, belongs to someone else, lacks
+// the connection's resume key or can no longer be resumed. A wrong key
+// leaves the connection alone.
+func (g *Gateway) resumeEvents(identity Identity, lastID string) (*Conn, *sseTransport, uint64) {
+	connID, rest, ok := strings.Cut(lastID, ":")
+	if !ok {
+		return nil, nil, 0
+	}
+	key, rawSeq, ok := strings.Cut(rest, ":")
+	if !ok {
+		return nil, nil, 0
+	}
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil {
+		return nil, nil, 0
+	}
+	c, ok := g.conns.get(connID)
+	if !ok || c.UserID() != identity.UserID {
+		return nil, nil, 0
+	}
+	t, ok := c.t.(*sseTransport)
+	if !ok || subtle.ConstantTimeCompare([]byte(key), []byte(t.resumeKey)) != 1 {
+		return nil, nil, 0
+	}
+	if !t.canResume(seq) {
+		c.close(closeNormal, reasonResumeGap)
+		return nil, nil, 0
+	}
+	return c, t, seq
+}
+
+func lastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("last_event_id")
+}
+
+// ServeIngress accepts one protojson Message per POST on a connection
+// opened by ServeEvents (or ServeWebSocket), named by the X-Connection-Id
+// header or connection_id query parameter. It answers 202 once the frame
+// was published, or the error Message with the HTTP status of its code
+// (400 invalid, 429 throttled, ...); the same error is not repeated on the
+// event stream.
+func (g *Gateway) ServeIngress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, codes.WithDetails(codes.ErrInvalidPayload, "method not allowed"))
+		return
+	}
+	identity, ok := g.authenticate(w, r)
+	if !ok {
+		return
+	}
+	connID := r.Header.Get(ConnectionIDHeader)
+	if connID == "" {
+		connID = r.URL.Query().Get("connection_id")
+	}
+	c, ok := g.conns.get(connID)
+	if !ok || c.UserID() != identity.UserID {
+		writeError(w, http.StatusNotFound, codes.WithDetails(codes.ErrInvalidPayload, errUnknownConnection.Error()))
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, g.opts.MaxMessageBytes))
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, codes.Wrap(codes.ErrInvalidPayload, err))
+		return
+	}
+	reply := g.ingress(r.Context(), c, data)
+	if reply == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	writeMessage(w, httpStatus(reply.GetError()), reply)
+}
+
+// httpStatus maps an error payload to the status of a POST response.
+func httpStatus(e *envelope.ErrorPayload) int {
+	switch e.GetErrorCode() {
+	case codes.ErrInvalidPayload.Symbol:
+		return http.StatusBadRequest
+	case codes.ErrUnauthorized.Symbol:
+		return http.StatusUnauthorized
+	case codes.ErrPermissionDenied.Symbol:
+		return http.StatusForbidden
+	case codes.ErrTooManyRequests.Symbol:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// sseWriter formats events onto one response.
+type sseWriter struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	timeout time.Duration
+	// idPrefix is "<connection_id>:<resume_key>".
+	idPrefix string
+}
+
+func (s *sseWriter) event(seq uint64, name string, data []byte) error {
+	return s.write(fmt.Sprintf("id: %s:%d\nevent: %s\ndata: %s\n\n", s.idPrefix, seq, name, data))
+}
+
+func (s *sseWriter) write(text string) error {
+	// Extends (or sets) the server's write deadline for this long-lived response.
+	if err := s.rc.SetWriteDeadline(time.Now().Add(s.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := io.WriteString(s.w, text); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// sseEvent is a buffered frame; done is cleared once it was reported.
+type sseEvent struct {
+	seq  uint64
+	data []byte
+	done func(error)
+}
+
+// sseTransport buffers a connection's frames between event streams. Frames
+// not yet written count against capacity (a full buffer closes the
+// connection as a slow consumer); written ones stay for replay until
+// displaced.
+type sseTransport struct {
+	capacity int
+	done     chan struct{}
+	onIdle   func()
+	// resumeKey must accompany a Last-Event-ID to resume the connection.
+	resumeKey string
+
+	mu        sync.Mutex
+	events    []sseEvent
+	nextSeq   uint64
+	written   uint64
+	gen       uint64
+	idle      *time.Timer
+	notify    chan struct{} // closed and replaced on every change
+	closed    bool
+	closeCode int
+	closeText string
+}
+
+func newSSETransport(capacity int) *sseTransport {
+	key := make([]byte, 16)
+	_, _ = rand.Read(key) // never fails, see crypto/rand.Read
+	return &sseTransport{capacity: capacity, done: make(chan struct{}), resumeKey: hex.EncodeToString(key), nextSeq: 1, notify: make(chan struct{})}
+}
+
+// wake signals stream loops; t.mu must be held.
+func (t *sseTransport) wake() {
+	close(t.notify)
+	t.notify = make(chan struct{})
+}
+
+func (t *sseTransport) send(f outbound) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrConnClosed
+	}
+	if t.nextSeq-1-t.written >= uint64(t.capacity) {
+		return ErrSlowConsumer
+	}
+	t.events = append(t.events, sseEvent{seq: t.nextSeq, data: f.data, done: f.done})
+	t.nextSeq++
+	for len(t.events) > t.capacity && t.events[0].seq <= t.written {
+		t.events = t.events[1:]
+	}
+	t.wake()
+	return nil
+}
+
+func (t *sseTransport) close(kind closeKind, reason string) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	t.closed = true
+	t.closeCode = wsCloseCode(kind)
+	t.closeText = reason
+	if t.idle != nil {
+		t.idle.Stop()
+	}
+	var pending []sseEvent
+	for _, ev := range t.events {
+		if ev.seq > t.written && ev.done != nil {
+			pending = append(pending, ev)
+		}
+	}
+	t.events = nil
+	t.wake()
+	close(t.done)
+	t.mu.Unlock()
+	for _, ev := range pending {
+		ev.done(ErrConnClosed)
+	}
+}
+
+// canResume reports whether every frame after seq is still buffered.
+func (t *sseTransport) canResume(seq uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed || seq >= t.nextSeq {
+		return false
+	}
+	oldest := t.nextSeq
+	if len(t.events) > 0 {
+		oldest = t.events[0].seq
+	}
+	return seq+1 >= oldest
+}
+
+// attach makes the caller the connection's only stream, ending any other.
+func (t *sseTransport) attach() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.gen++
+	if t.idle != nil {
+		t.idle.Stop()
+		t.idle = nil
+	}
+	t.wake()
+	return t.gen
+}
+
+// detach starts the resume window once the current stream ends.
+func (t *sseTransport) detach(gen uint64, window time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed || gen != t.gen {
+		return
+	}
+	t.idle = time.AfterFunc(window, t.onIdle)
+}
+
+// stream writes frames after cursor until the client goes away, another
+// stream attaches or the connection closes.
+func (t *sseTransport) stream(ctx context.Context, s *sseWriter, gen, cursor uint64, pingInterval time.Duration) {
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		t.mu.Lock()
+		if t.gen != gen {
+			t.mu.Unlock()
+			return
+		}
+		if t.closed {
+			data := fmt.Sprintf(`{"code":%d,"reason":%q}`, t.closeCode, t.closeText)
+			t.mu.Unlock()
+			_ = s.event(cursor, "close", []byte(data))
+			return
+		}
+		var batch []sseEvent
+		for _, ev := range t.events {
+			if ev.seq > cursor {
+				batch = append(batch, ev)
+			}
+		}
+		notify := t.notify
+		t.mu.Unlock()
+
+		if len(batch) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-notify:
+			case <-ping.C:
+				if err := s.write(": ping\n\n"); err != nil {
+					return
+				}
+			}
+			continue
+		}
+		for _, ev := range batch {
+			if err := s.event(ev.seq, "message", ev.data); err != nil {
+				return
+			}
+			cursor = ev.seq
+		}
+		t.markWritten(cursor)
+	}
+}
+
+// markWritten records that frames up to seq reached a client and reports them.
+func (t *sseTransport) markWritten(seq uint64) {
+	t.mu.Lock()
+	var finished []func(error)
+	for i := range t.events {
+		ev := &t.events[i]
+		if ev.seq <= seq && ev.done != nil {
+			finished = append(finished, ev.done)
+			ev.done = nil
+		}
+	}
+	t.written = max(t.written, seq)
+	t.mu.Unlock()
+	for _, done := range finished {
+		done(nil)
+	}
+}
@@ -0,0 +1,190 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/Goden-Gun/transport-lib/pkg/logger"
+)
+
+// ServeWebSocket upgrades an authenticated request to a WebSocket
+// connection. Client frames are protojson Messages (text or binary); every
+// frame the gateway writes is a text protojson Message.
+func (g *Gateway) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	identity, ok := g.authenticate(w, r)
+	if !ok {
+		return
+	}
+	upgrader := websocket.Upgrader{CheckOrigin: g.opts.CheckOrigin}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already answered the request.
+		return
+	}
+	t := &wsTransport{
+		ws:    ws,
+		queue: make(chan outbound, g.opts.SendBuffer),
+		done:  make(chan struct{}),
+	}
+	ctx := context.WithoutCancel(r.Context())
+	c, err := g.open(ctx, identity, r.RemoteAddr, t)
+	if err != nil {
+		// Close started after authentication; answer like a shutdown.
+		msg := websocket.FormatCloseMessage(wsCloseCode(closeGoingAway), reasonShutdown)
+		_ = ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(g.opts.WriteTimeout))
+		_ = ws.Close()
+		return
+	}
+	go t.writePump(g.opts.WriteTimeout, g.opts.PingInterval)
+	g.readLoop(ctx, c, t)
+	g.disconnect(c, reasonClientClosed)
+}
+
+func (g *Gateway) readLoop(ctx context.Context, c *Conn, t *wsTransport) {
+	pongWait := 2 * g.opts.PingInterval
+	t.ws.SetReadLimit(g.opts.MaxMessageBytes)
+	_ = t.ws.SetReadDeadline(time.Now().Add(pongWait))
+	t.ws.SetPongHandler(func(string) error {
+		return t.ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := t.ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !t.isClosed() {
+				logger.WithError(err).WithField("connection_id", c.id).Debug("gateway: websocket read")
+			}
+			return
+		}
+		_ = t.ws.SetReadDeadline(time.Now().Add(pongWait))
+		reply := g.ingress(ctx, c, data)
+		if reply == nil {
+			continue
+		}
+		if data, err := marshalMessage(reply); err == nil {
+			_ = c.send(outbound{data: data})
+		}
+	}
+}
+
+// wsTransport serialises writes to one WebSocket through writePump.
+type wsTransport struct {
+	ws    *websocket.Conn
+	queue chan outbound
+
+	mu        sync.Mutex
+	closed    bool
+	done      chan struct{}
+	closeCode int
+	closeText string
+}
+
+func (t *wsTransport) send(f outbound) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrConnClosed
+	}
+	select {
+	case t.queue <- f:
+		return nil
+	default:
+		return ErrSlowConsumer
+	}
+}
+
+func (t *wsTransport) close(kind closeKind, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	t.closeCode = wsCloseCode(kind)
+	t.closeText = truncateUTF8(reason, maxCloseReason)
+	close(t.done)
+}
+
+func (t *wsTransport) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+func wsCloseCode(kind closeKind) int {
+	switch kind {
+	case closePolicy:
+		return websocket.ClosePolicyViolation
+	case closeGoingAway:
+		return websocket.CloseGoingAway
+	case closeTryAgain:
+		return websocket.CloseTryAgainLater
+	}
+	return websocket.CloseNormalClosure
+}
+
+// writePump owns all writes to the socket: queued frames, pings and the
+// final close frame. Frames still queued when the connection closes fail
+// with ErrConnClosed.
+func (t *wsTransport) writePump(writeTimeout, pingInterval time.Duration) {
+	ping := time.NewTicker(pingInterval)
+	defer func() {
+		ping.Stop()
+		_ = t.ws.Close()
+		for {
+			select {
+			case f := <-t.queue:
+				f.finish(ErrConnClosed)
+			default:
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case f := <-t.queue:
+			_ = t.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+			err := t.ws.WriteMessage(websocket.TextMessage, f.data)
+			f.finish(err)
+			if err != nil {
+				t.close(closeNormal, "")
+				return
+			}
+		case <-ping.C:
+			deadline := time.Now().Add(writeTimeout)
+			if err := t.ws.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				t.close(closeNormal, "")
+				return
+			}
+		case <-t.done:
+			t.mu.Lock()
+			msg := websocket.FormatCloseMessage(t.closeCode, t.closeText)
+			t.mu.Unlock()
+			err := t.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeTimeout))
+			if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+				logger.WithError(err).Debug("gateway: websocket close frame")
+			}
+			return
+		}
+	}
+}
+
+// maxCloseReason is the close frame payload limit of 125 bytes minus the code.
+const maxCloseReason = 123
+
+// truncateUTF8 cuts s to at most n bytes without splitting a UTF-8 sequence,
+// which would make the close frame invalid.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
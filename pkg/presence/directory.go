@@ -0,0 +1,274 @@
+package presence
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Goden-Gun/transport-lib/pkg/config"
+)
+
+// refreshScript extends an existing entry only, so a refresh racing with an
+// unregister does not resurrect the connection.
+var refreshScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+  redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+  redis.call('PEXPIRE', KEYS[1], ARGV[3])
+  return 1
+end
+return 0
+`)
+
+// Connection is one client connection recorded in the directory.
+type Connection struct {
+	UserID       int64
+	ConnectionID string
+	NodeID       string
+	ExpiresAt    time.Time
+}
+
+// Directory records which sidecar node holds each user's connections.
+//
+// Keys: {prefix}:presence:user:{user_id} (hash connection_id -> "node_id|expires_at_ms",
+// TTL refreshed on every write) and {prefix}:presence:node:{node_id} (set of
+// "user_id/connection_id", used to refresh and clear a node's entries).
+type Directory struct {
+	client          redis.UniversalClient
+	prefix          string
+	ttl             time.Duration
+	refreshInterval time.Duration
+}
+
+// NewDirectory creates a Directory using cfg.RedisPrefix and TTLs.
+func NewDirectory(client redis.UniversalClient, cfg config.PresenceConfig) *Directory {
+	if client == nil {
+		return nil
+	}
+	cfg.ApplyDefaults()
+	return &Directory{
+		client:          client,
+		prefix:          cfg.RedisPrefix,
+		ttl:             time.Duration(cfg.TTLSeconds) * time.Second,
+		refreshInterval: time.Duration(cfg.RefreshIntervalSeconds) * time.Second,
+	}
+}
+
+// Register records connectionID of userID on nodeID.
+func (d *Directory) Register(ctx context.Context, nodeID string, userID int64, connectionID string) error {
+	if d == nil {
+		return fmt.Errorf("presence directory not configured")
+	}
+	if nodeID == "" || connectionID == "" {
+		return fmt.Errorf("node id and connection id are required")
+	}
+	_, err := d.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, d.userKey(userID), connectionID, d.entry(nodeID))
+		p.PExpire(ctx, d.userKey(userID), d.ttl)
+		p.SAdd(ctx, d.nodeKey(nodeID), member(userID, connectionID))
+		p.PExpire(ctx, d.nodeKey(nodeID), d.ttl)
+		return nil
+	})
+	return err
+}
+
+// Unregister removes connectionID of userID from nodeID.
+func (d *Directory) Unregister(ctx context.Context, nodeID string, userID int64, connectionID string) error {
+	if d == nil {
+		return fmt.Errorf("presence directory not configured")
+	}
+	_, err := d.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.HDel(ctx, d.userKey(userID), connectionID)
+		p.SRem(ctx, d.nodeKey(nodeID), member(userID, connectionID))
+		return nil
+	})
+	return err
+}
+
+// Refresh extends the TTL of every connection registered by nodeID.
+func (d *Directory) Refresh(ctx context.Context, nodeID string) error {
+	if d == nil {
+		return fmt.Errorf("presence directory not configured")
+	}
+	members, err := d.client.SMembers(ctx, d.nodeKey(nodeID)).Result()
+	if err != nil || len(members) == 0 {
+		return err
+	}
+	entry := d.entry(nodeID)
+	_, err = d.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, m := range members {
+			userID, connectionID, ok := parseMember(m)
+			if !ok {
+				p.SRem(ctx, d.nodeKey(nodeID), m)
+				continue
+			}
+			refreshScript.Eval(ctx, p, []string{d.userKey(userID)}, connectionID, entry, d.ttl.Milliseconds())
+		}
+		p.PExpire(ctx, d.nodeKey(nodeID), d.ttl)
+		return nil
+	})
+	return err
+}
+
+// RefreshInterval is how often a node's entries are refreshed:
+// RefreshIntervalSeconds, capped at half the TTL. The bridge server refreshes
+// on heartbeats no more often than this.
+func (d *Directory) RefreshInterval() time.Duration {
+	if d == nil {
+		return 0
+	}
+	return min(d.refreshInterval, d.ttl/2)
+}
+
+// RefreshLoop refreshes nodeID every RefreshIntervalSeconds until ctx is done.
+func (d *Directory) RefreshLoop(ctx context.Context, nodeID string) error {
+	if d == nil {
+		return fmt.Errorf("presence directory not configured")
+	}
+	ticker := time.NewTicker(d.RefreshInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			_ = d.Refresh(ctx, nodeID)
+		}
+	}
+}
+
+// ClearNode removes every connection registered by nodeID, e.g. on graceful shutdown.
+func (d *Directory) ClearNode(ctx context.Context, nodeID string) error {
+	if d == nil {
+		return fmt.Errorf("presence directory not configured")
+	}
+	members, err := d.client.SMembers(ctx, d.nodeKey(nodeID)).Result()
+	if err != nil {
+		return err
+	}
+	_, err = d.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, m := range members {
+			if userID, connectionID, ok := parseMember(m); ok {
+				p.HDel(ctx, d.userKey(userID), connectionID)
+			}
+		}
+		p.Del(ctx, d.nodeKey(nodeID))
+		return nil
+	})
+	return err
+}
+
+// Lookup returns the live connections of userID.
+func (d *Directory) Lookup(ctx context.Context, userID int64) ([]Connection, error) {
+	res, err := d.LookupMany(ctx, []int64{userID})
+	if err != nil {
+		return nil, err
+	}
+	return res[userID], nil
+}
+
+// LookupMany returns the live connections of each user; offline users are omitted.
+func (d *Directory) LookupMany(ctx context.Context, userIDs []int64) (map[int64][]Connection, error) {
+	if d == nil {
+		return nil, fmt.Errorf("presence directory not configured")
+	}
+	if len(userIDs) == 0 {
+		return map[int64][]Connection{}, nil
+	}
+	cmds := make([]*redis.MapStringStringCmd, len(userIDs))
+	_, err := d.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, userID := range userIDs {
+			cmds[i] = p.HGetAll(ctx, d.userKey(userID))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make(map[int64][]Connection, len(userIDs))
+	stale := map[int64][]string{}
+	for i, userID := range userIDs {
+		for connectionID, value := range cmds[i].Val() {
+			nodeID, expiresAt, ok := parseEntry(value)
+			if !ok || expiresAt.Before(now) {
+				stale[userID] = append(stale[userID], connectionID)
+				continue
+			}
+			out[userID] = append(out[userID], Connection{UserID: userID, ConnectionID: connectionID, NodeID: nodeID, ExpiresAt: expiresAt})
+		}
+	}
+	if len(stale) > 0 {
+		// Best effort cleanup of entries whose node stopped refreshing.
+		_, _ = d.client.Pipelined(ctx, func(p redis.Pipeliner) error {
+			for userID, conns := range stale {
+				p.HDel(ctx, d.userKey(userID), conns...)
+			}
+			return nil
+		})
+	}
+	return out, nil
+}
+
+// NodesForUsers groups users by the nodes holding at least one of their connections.
+func (d *Directory) NodesForUsers(ctx context.Context, userIDs []int64) (map[string][]int64, error) {
+	conns, err := d.LookupMany(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string][]int64{}
+	for _, userID := range userIDs {
+		seen := map[string]bool{}
+		for _, c := range conns[userID] {
+			if seen[c.NodeID] {
+				continue
+			}
+			seen[c.NodeID] = true
+			out[c.NodeID] = append(out[c.NodeID], userID)
+		}
+	}
+	return out, nil
+}
+
+func (d *Directory) entry(nodeID string) string {
+	return nodeID + "|" + strconv.FormatInt(time.Now().Add(d.ttl).UnixMilli(), 10)
+}
+
+func (d *Directory) userKey(userID int64) string {
+	return fmt.Sprintf("%s:presence:user:%d", d.prefix, userID)
+}
+
+func (d *Directory) nodeKey(nodeID string) string {
+	return fmt.Sprintf("%s:presence:node:%s", d.prefix, nodeID)
+}
+
+func member(userID int64, connectionID string) string {
+	return strconv.FormatInt(userID, 10) + "/" + connectionID
+}
+
+func parseMember(m string) (int64, string, bool) {
+	uid, conn, ok := strings.Cut(m, "/")
+	if !ok {
+		return 0, "", false
+	}
+	userID, err := strconv.ParseInt(uid, 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return userID, conn, true
+}
+
+func parseEntry(value string) (string, time.Time, bool) {
+	i := strings.LastIndexByte(value, '|')
+	if i < 0 {
+		return "", time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(value[i+1:], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return value[:i], time.UnixMilli(ms), true
+}
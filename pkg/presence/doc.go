@@ -0,0 +1,18 @@
+// Package presence keeps a cluster-wide directory of client connections in
+// Redis, mapping user_id to the sidecar nodes (and connection ids) holding
+// them, so workers can route user-targeted deliveries to the right bridge
+// session.
+//
+// Entries expire after PresenceConfig.TTLSeconds unless refreshed; a node
+// refreshes all of its entries at once with Directory.Refresh, at most every
+// Directory.RefreshInterval (the bridge server does it on heartbeats), so a
+// crashed node's connections disappear on their own.
+//
+// Example (worker, with the bridge server keeping the directory up to date
+// from connection events and heartbeats):
+//
+//	dir := presence.NewDirectory(redisClient, cfg.Presence)
+//	srv, _ := bridge.NewServer(bridge.Options{Address: ":50051", Presence: dir})
+//	// later, from any handler:
+//	_, _ = srv.Deliver(ctx, &envelope.TransportEnvelope{TargetUserIds: []int64{42}, Message: msg})
+package presence
@@ -0,0 +1,29 @@
+// Package slot implements Redis-backed slot leases shared by workers and
+// sidecars, including a live handoff protocol for scale-down.
+//
+// Handoff flow:
+//
+//  1. The departing worker calls Manager.Handoff, which marks its slots as
+//     migrating (optionally targeted at a specific node) and publishes a
+//     route change event.
+//  2. A new owner (Manager.ClaimMigrating or an explicit Manager.Acquire)
+//     takes the slot; the lease generation is bumped and an "acquired" event
+//     is published.
+//  3. Sidecars running Router.Run refresh their cached routes from the
+//     events. Ingress dispatched via Router.Dispatch while the slot was
+//     migrating or unassigned is held and replayed to the new owner, stamped
+//     with the new generation; ingress dispatched during the replay is sent
+//     after it.
+//
+// Slots not claimed within SlotConfig.MigrationTimeoutSeconds, or when the
+// Handoff context ends first, are released.
+//
+// Example (worker):
+//
+//	store := slot.NewRedisStore(redisClient, cfg.Slot)
+//	mgr, _ := slot.NewManager(store, nodeID, cfg.Slot)
+//	go mgr.Run(ctx)
+//	go mgr.ClaimMigrating(ctx, nil)
+//	// on SIGTERM
+//	_ = mgr.Handoff(shutdownCtx, "")
+package slot
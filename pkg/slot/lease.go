@@ -0,0 +1,79 @@
+package slot
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrSlotOwned indicates the slot is held by another owner and is not being handed off.
+	ErrSlotOwned = errors.New("slot owned by another node")
+	// ErrNotOwner indicates the caller no longer holds the slot lease.
+	ErrNotOwner = errors.New("slot not owned by caller")
+	// ErrSlotUnassigned indicates no node currently owns the slot.
+	ErrSlotUnassigned = errors.New("slot unassigned")
+	// ErrSlotMigrating indicates the slot is being handed off to a new owner.
+	ErrSlotMigrating = errors.New("slot migrating")
+	// ErrReplayBufferFull indicates too much ingress is already held for a slot.
+	ErrReplayBufferFull = errors.New("slot replay buffer full")
+)
+
+// State describes the lifecycle of a slot lease.
+type State string
+
+const (
+	// StateActive means the owner is serving the slot.
+	StateActive State = "active"
+	// StateMigrating means the owner is leaving and a new owner may acquire the slot.
+	StateMigrating State = "migrating"
+)
+
+// Lease is the ownership record of a single slot.
+type Lease struct {
+	Slot       uint32
+	Owner      string
+	Generation uint32
+	State      State
+	// Target optionally restricts which node may take over a migrating slot.
+	Target string
+}
+
+// EventType enumerates route change notifications.
+type EventType string
+
+const (
+	EventAcquired  EventType = "acquired"
+	EventMigrating EventType = "migrating"
+	EventReleased  EventType = "released"
+)
+
+// Event is published whenever slot ownership changes so sidecars can refresh routes.
+type Event struct {
+	Type       EventType `json:"type"`
+	Slot       uint32    `json:"slot"`
+	Owner      string    `json:"owner"`
+	Generation uint32    `json:"generation"`
+	PrevOwner  string    `json:"prev_owner,omitempty"`
+	Target     string    `json:"target,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// Store persists slot leases and fans out route change events.
+type Store interface {
+	// Acquire takes an unassigned or migrating slot, bumping its generation.
+	// acquired is false when the caller already owned the slot (lease renewed).
+	Acquire(ctx context.Context, slot uint32, owner string) (lease Lease, acquired bool, err error)
+	// Renew extends the lease TTL; it returns ErrNotOwner if the lease was lost.
+	Renew(ctx context.Context, slot uint32, owner string) error
+	// BeginMigration marks an owned slot as migrating to target ("" = any node).
+	BeginMigration(ctx context.Context, slot uint32, owner, target string) (Lease, error)
+	// Release drops an owned lease.
+	Release(ctx context.Context, slot uint32, owner string) error
+	// Get returns the current lease; ok is false when the slot is unassigned.
+	Get(ctx context.Context, slot uint32) (lease Lease, ok bool, err error)
+	// Publish broadcasts a route change event.
+	Publish(ctx context.Context, ev Event) error
+	// Subscribe streams route change events until ctx is cancelled.
+	Subscribe(ctx context.Context) (<-chan Event, error)
+}
@@ -0,0 +1,224 @@
+package slot
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/config"
+)
+
+// handoffReleaseTimeout bounds releasing the slots of a cancelled handoff.
+const handoffReleaseTimeout = 5 * time.Second
+
+// Manager tracks the slots a worker owns, renews their leases and drives handoff.
+type Manager struct {
+	store  Store
+	nodeID string
+	cfg    config.SlotConfig
+
+	mu    sync.RWMutex
+	owned map[uint32]Lease
+
+	// OnLost is invoked when a lease could not be renewed.
+	OnLost func(Lease)
+}
+
+// NewManager creates a worker-side slot manager for nodeID.
+func NewManager(store Store, nodeID string, cfg config.SlotConfig) (*Manager, error) {
+	if store == nil {
+		return nil, errors.New("slot store is required")
+	}
+	if nodeID == "" {
+		return nil, errors.New("node id is required")
+	}
+	cfg.ApplyDefaults()
+	return &Manager{store: store, nodeID: nodeID, cfg: cfg, owned: map[uint32]Lease{}}, nil
+}
+
+// Acquire tries to take each slot and returns the leases now held.
+// Slots owned by other active nodes are skipped.
+func (m *Manager) Acquire(ctx context.Context, slots ...uint32) ([]Lease, error) {
+	var held []Lease
+	for _, id := range slots {
+		lease, _, err := m.store.Acquire(ctx, id, m.nodeID)
+		if errors.Is(err, ErrSlotOwned) {
+			continue
+		}
+		if err != nil {
+			return held, err
+		}
+		m.mu.Lock()
+		m.owned[id] = lease
+		m.mu.Unlock()
+		held = append(held, lease)
+	}
+	return held, nil
+}
+
+// Owned returns the leases currently held, ordered by slot id.
+func (m *Manager) Owned() []Lease {
+	m.mu.RLock()
+	out := make([]Lease, 0, len(m.owned))
+	for _, l := range m.owned {
+		out = append(out, l)
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Slot < out[j].Slot })
+	return out
+}
+
+// Generation returns the generation of an owned slot.
+func (m *Manager) Generation(slot uint32) (uint32, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	l, ok := m.owned[slot]
+	return l.Generation, ok
+}
+
+// Run renews owned leases every RenewIntervalSeconds until ctx is done.
+func (m *Manager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(time.Duration(m.cfg.RenewIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.renew(ctx)
+		}
+	}
+}
+
+// ClaimMigrating listens for migrating slots and acquires those open to this
+// node until ctx is done. accept may filter slots (nil accepts all).
+func (m *Manager) ClaimMigrating(ctx context.Context, accept func(Event) bool) error {
+	events, err := m.store.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+	for ev := range events {
+		if ev.Type != EventMigrating || ev.Owner == m.nodeID {
+			continue
+		}
+		if ev.Target != "" && ev.Target != m.nodeID {
+			continue
+		}
+		if accept != nil && !accept(ev) {
+			continue
+		}
+		// Best effort: another node may win the race, and a failed attempt
+		// leaves the slot migrating for the next candidate.
+		_, _ = m.Acquire(ctx, ev.Slot)
+	}
+	return ctx.Err()
+}
+
+// Handoff marks slots as migrating to target ("" = any node), waits up to
+// MigrationTimeoutSeconds for a new owner to take them and releases whatever
+// is left. With no slots given, every owned slot is handed off. When ctx ends
+// first, the slots not taken yet are released too, so none stays migrating
+// on a node that stopped waiting.
+func (m *Manager) Handoff(ctx context.Context, target string, slots ...uint32) error {
+	if len(slots) == 0 {
+		for _, l := range m.Owned() {
+			slots = append(slots, l.Slot)
+		}
+	}
+	pending := map[uint32]struct{}{}
+	for _, id := range slots {
+		lease, err := m.store.BeginMigration(ctx, id, m.nodeID, target)
+		if errors.Is(err, ErrNotOwner) {
+			m.forget(id)
+			continue
+		}
+		if err != nil {
+			return errors.Join(err, m.releasePending(ctx, pending))
+		}
+		m.mu.Lock()
+		m.owned[id] = lease
+		m.mu.Unlock()
+		pending[id] = struct{}{}
+	}
+	timeout := time.Duration(m.cfg.MigrationTimeoutSeconds) * time.Second
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(200 * time.Millisecond)
+	defer poll.Stop()
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), m.releasePending(ctx, pending))
+		case <-deadline.C:
+			return m.releasePending(ctx, pending)
+		case <-poll.C:
+			for id := range pending {
+				lease, ok, err := m.store.Get(ctx, id)
+				if err != nil {
+					return err
+				}
+				if !ok || lease.Owner != m.nodeID {
+					delete(pending, id)
+					m.forget(id)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Release drops the given slots (all owned slots when none given).
+func (m *Manager) Release(ctx context.Context, slots ...uint32) error {
+	if len(slots) == 0 {
+		for _, l := range m.Owned() {
+			slots = append(slots, l.Slot)
+		}
+	}
+	var firstErr error
+	for _, id := range slots {
+		if err := m.store.Release(ctx, id, m.nodeID); err != nil && !errors.Is(err, ErrNotOwner) && firstErr == nil {
+			firstErr = err
+		}
+		m.forget(id)
+	}
+	return firstErr
+}
+
+// releasePending releases the slots of an unfinished handoff. It outlives
+// ctx, bounded by handoffReleaseTimeout, since a cancelled handoff must not
+// leave its slots migrating until the lease expires.
+func (m *Manager) releasePending(ctx context.Context, pending map[uint32]struct{}) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), handoffReleaseTimeout)
+	defer cancel()
+	var errs []error
+	for id := range pending {
+		if err := m.store.Release(ctx, id, m.nodeID); err != nil && !errors.Is(err, ErrNotOwner) {
+			errs = append(errs, err)
+		}
+		m.forget(id)
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) renew(ctx context.Context) {
+	for _, l := range m.Owned() {
+		err := m.store.Renew(ctx, l.Slot, m.nodeID)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrNotOwner) {
+			m.forget(l.Slot)
+			if m.OnLost != nil {
+				m.OnLost(l)
+			}
+		}
+	}
+}
+
+func (m *Manager) forget(slot uint32) {
+	m.mu.Lock()
+	delete(m.owned, slot)
+	m.mu.Unlock()
+}
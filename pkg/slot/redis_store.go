@@ -0,0 +1,271 @@
+package slot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Goden-Gun/transport-lib/pkg/config"
+)
+
+// acquireScript returns {owner, generation, state, target, result, prev_owner}
+// where result is 1 (acquired), 0 (already owned, renewed) or -1 (conflict).
+var acquireScript = redis.NewScript(`
+local prev = ''
+local cur = redis.call('HGETALL', KEYS[1])
+if #cur > 0 then
+  local h = {}
+  for i = 1, #cur, 2 do h[cur[i]] = cur[i + 1] end
+  local target = h['target'] or ''
+  if h['owner'] == ARGV[1] then
+    if h['state'] ~= 'migrating' then
+      redis.call('PEXPIRE', KEYS[1], ARGV[2])
+    end
+    return {h['owner'], tonumber(h['generation']), h['state'], target, 0, ''}
+  end
+  if h['state'] ~= 'migrating' or (target ~= '' and target ~= ARGV[1]) then
+    return {h['owner'], tonumber(h['generation']), h['state'], target, -1, ''}
+  end
+  prev = h['owner']
+end
+local gen = redis.call('INCR', KEYS[2])
+redis.call('DEL', KEYS[1])
+redis.call('HSET', KEYS[1], 'owner', ARGV[1], 'generation', gen, 'state', 'active', 'target', '', 'updated_at', ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return {ARGV[1], gen, 'active', '', 1, prev}
+`)
+
+// renewScript extends an owned lease; a migrating lease keeps the deadline
+// set by BeginMigration so an abandoned handoff still expires.
+var renewScript = redis.NewScript(`
+local cur = redis.call('HMGET', KEYS[1], 'owner', 'state')
+if cur[1] ~= ARGV[1] then
+  return 0
+end
+if cur[2] == 'migrating' then
+  return 1
+end
+return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+`)
+
+var migrateScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+  return -1
+end
+redis.call('HSET', KEYS[1], 'state', 'migrating', 'target', ARGV[2], 'updated_at', ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return tonumber(redis.call('HGET', KEYS[1], 'generation'))
+`)
+
+var releaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisStore keeps slot leases in Redis hashes and publishes route events on a channel.
+//
+// Keys: <prefix>:{slot:<id>}:lease (hash, TTL = lease TTL) and
+// <prefix>:{slot:<id>}:gen (generation counter, no TTL so generations never
+// go backwards). The {slot:<id>} hash tag keeps both keys of a slot in one
+// Redis Cluster slot, as the scripts touch them together.
+type RedisStore struct {
+	client       redis.UniversalClient
+	prefix       string
+	leaseTTL     time.Duration
+	migrationTTL time.Duration
+}
+
+// NewRedisStore creates a Store using cfg.RedisPrefix and lease timings.
+func NewRedisStore(client redis.UniversalClient, cfg config.SlotConfig) *RedisStore {
+	if client == nil {
+		return nil
+	}
+	cfg.ApplyDefaults()
+	return &RedisStore{
+		client:       client,
+		prefix:       cfg.RedisPrefix,
+		leaseTTL:     time.Duration(cfg.LeaseTTLSeconds) * time.Second,
+		migrationTTL: time.Duration(cfg.MigrationTimeoutSeconds) * time.Second,
+	}
+}
+
+func (s *RedisStore) Acquire(ctx context.Context, slot uint32, owner string) (Lease, bool, error) {
+	if s == nil || owner == "" {
+		return Lease{}, false, fmt.Errorf("slot store not configured")
+	}
+	keys := []string{s.leaseKey(slot), s.genKey(slot)}
+	res, err := acquireScript.Run(ctx, s.client, keys, owner, s.leaseTTL.Milliseconds(), time.Now().UnixMilli()).Slice()
+	if err != nil {
+		return Lease{}, false, err
+	}
+	if len(res) != 6 {
+		return Lease{}, false, fmt.Errorf("unexpected acquire reply: %v", res)
+	}
+	lease := Lease{
+		Slot:       slot,
+		Owner:      toString(res[0]),
+		Generation: uint32(toInt64(res[1])),
+		State:      State(toString(res[2])),
+		Target:     toString(res[3]),
+	}
+	switch toInt64(res[4]) {
+	case 1:
+		ev := Event{Type: EventAcquired, Slot: slot, Owner: owner, Generation: lease.Generation, PrevOwner: toString(res[5]), At: time.Now()}
+		if err := s.Publish(ctx, ev); err != nil {
+			return lease, true, err
+		}
+		return lease, true, nil
+	case 0:
+		return lease, false, nil
+	default:
+		return lease, false, ErrSlotOwned
+	}
+}
+
+func (s *RedisStore) Renew(ctx context.Context, slot uint32, owner string) error {
+	if s == nil || owner == "" {
+		return fmt.Errorf("slot store not configured")
+	}
+	ok, err := renewScript.Run(ctx, s.client, []string{s.leaseKey(slot)}, owner, s.leaseTTL.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+func (s *RedisStore) BeginMigration(ctx context.Context, slot uint32, owner, target string) (Lease, error) {
+	if s == nil || owner == "" {
+		return Lease{}, fmt.Errorf("slot store not configured")
+	}
+	gen, err := migrateScript.Run(ctx, s.client, []string{s.leaseKey(slot)}, owner, target, s.migrationTTL.Milliseconds(), time.Now().UnixMilli()).Int64()
+	if err != nil {
+		return Lease{}, err
+	}
+	if gen < 0 {
+		return Lease{}, ErrNotOwner
+	}
+	lease := Lease{Slot: slot, Owner: owner, Generation: uint32(gen), State: StateMigrating, Target: target}
+	ev := Event{Type: EventMigrating, Slot: slot, Owner: owner, Generation: lease.Generation, Target: target, At: time.Now()}
+	return lease, s.Publish(ctx, ev)
+}
+
+func (s *RedisStore) Release(ctx context.Context, slot uint32, owner string) error {
+	if s == nil || owner == "" {
+		return fmt.Errorf("slot store not configured")
+	}
+	n, err := releaseScript.Run(ctx, s.client, []string{s.leaseKey(slot)}, owner).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotOwner
+	}
+	return s.Publish(ctx, Event{Type: EventReleased, Slot: slot, PrevOwner: owner, At: time.Now()})
+}
+
+func (s *RedisStore) Get(ctx context.Context, slot uint32) (Lease, bool, error) {
+	if s == nil {
+		return Lease{}, false, fmt.Errorf("slot store not configured")
+	}
+	h, err := s.client.HGetAll(ctx, s.leaseKey(slot)).Result()
+	if err != nil {
+		return Lease{}, false, err
+	}
+	if len(h) == 0 {
+		return Lease{}, false, nil
+	}
+	gen, _ := strconv.ParseUint(h["generation"], 10, 32)
+	return Lease{Slot: slot, Owner: h["owner"], Generation: uint32(gen), State: State(h["state"]), Target: h["target"]}, true, nil
+}
+
+func (s *RedisStore) Publish(ctx context.Context, ev Event) error {
+	if s == nil {
+		return fmt.Errorf("slot store not configured")
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return s.client.Publish(ctx, s.eventChannel(), data).Err()
+}
+
+func (s *RedisStore) Subscribe(ctx context.Context) (<-chan Event, error) {
+	if s == nil {
+		return nil, fmt.Errorf("slot store not configured")
+	}
+	sub := s.client.Subscribe(ctx, s.eventChannel())
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+	out := make(chan Event, 64)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+		msgs := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var ev Event
+				if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+					continue
+				}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *RedisStore) leaseKey(slot uint32) string {
+	return fmt.Sprintf("%s:{slot:%d}:lease", s.prefix, slot)
+}
+
+func (s *RedisStore) genKey(slot uint32) string {
+	return fmt.Sprintf("%s:{slot:%d}:gen", s.prefix, slot)
+}
+
+func (s *RedisStore) eventChannel() string {
+	return s.prefix + ":slot:events"
+}
+
+func toString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func toInt64(v any) int64 {
+	switch t := v.(type) {
+	case int64:
+		return t
+	case string:
+		n, _ := strconv.ParseInt(t, 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,298 @@
+package slot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Goden-Gun/transport-lib/pkg/config"
+	"github.com/Goden-Gun/transport-lib/pkg/envelope"
+)
+
+// SendFunc forwards an envelope to the worker owning lease.
+type SendFunc func(ctx context.Context, lease Lease, env *envelope.TransportEnvelope) error
+
+// Router resolves envelopes to slot owners on the sidecar side. It caches
+// leases for RouteTTLSeconds, refreshes them from route change events and holds
+// ingress for migrating slots, replaying it to the new owner once acquired.
+type Router struct {
+	store Store
+	cfg   config.SlotConfig
+	keyFn envelope.SlotKeyFunc
+
+	mu      sync.Mutex
+	routes  map[uint32]cachedRoute
+	pending map[uint32][]heldEnvelope
+	// draining marks slots whose held envelopes are being replayed; Dispatch
+	// queues behind them until the queue is empty.
+	draining map[uint32]bool
+
+	// OnRouteChange is invoked for every route change event, after the cache is updated.
+	OnRouteChange func(Event)
+	// OnDrop is invoked for held envelopes that could not be replayed in time.
+	OnDrop func(env *envelope.TransportEnvelope, err error)
+}
+
+type cachedRoute struct {
+	lease     Lease
+	ok        bool
+	expiresAt time.Time
+}
+
+type heldEnvelope struct {
+	env    *envelope.TransportEnvelope
+	send   SendFunc
+	heldAt time.Time
+}
+
+// NewRouter creates a sidecar-side router keyed by cfg.KeySource. Unknown key
+// sources and TotalSlots above envelope.MaxTotalSlots are rejected.
+func NewRouter(store Store, cfg config.SlotConfig) (*Router, error) {
+	if store == nil {
+		return nil, errors.New("slot store is required")
+	}
+	cfg.ApplyDefaults()
+	if cfg.TotalSlots > envelope.MaxTotalSlots {
+		return nil, fmt.Errorf("total slots %d exceeds %d", cfg.TotalSlots, envelope.MaxTotalSlots)
+	}
+	keyFn, err := envelope.SlotKeyFromSpec(cfg.KeySource)
+	if err != nil {
+		return nil, err
+	}
+	return &Router{
+		store:    store,
+		cfg:      cfg,
+		keyFn:    keyFn,
+		routes:   map[uint32]cachedRoute{},
+		pending:  map[uint32][]heldEnvelope{},
+		draining: map[uint32]bool{},
+	}, nil
+}
+
+// Run applies route change events and expires held ingress until ctx is done.
+func (r *Router) Run(ctx context.Context) error {
+	events, err := r.store.Subscribe(ctx)
+	if err != nil {
+		return err
+	}
+	sweep := time.NewTicker(time.Second)
+	defer sweep.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				return ctx.Err()
+			}
+			r.apply(ctx, ev)
+		case <-sweep.C:
+			r.expireHeld()
+		}
+	}
+}
+
+// Resolve stamps the slot on env and returns its current lease.
+// It returns ErrSlotMigrating or ErrSlotUnassigned when no owner can take traffic.
+func (r *Router) Resolve(ctx context.Context, env *envelope.TransportEnvelope) (Lease, error) {
+	slotID, ok := envelope.EnvelopeSlot(env, r.keyFn, r.cfg.TotalSlots)
+	if !ok {
+		return Lease{}, errors.New("envelope has no slot key")
+	}
+	lease, found, err := r.lookup(ctx, slotID)
+	if err != nil {
+		return Lease{}, err
+	}
+	if !found {
+		envelope.SetSlot(env, slotID, 0)
+		return Lease{Slot: slotID}, ErrSlotUnassigned
+	}
+	envelope.SetSlot(env, slotID, lease.Generation)
+	if lease.State == StateMigrating {
+		return lease, ErrSlotMigrating
+	}
+	return lease, nil
+}
+
+// Dispatch resolves env and forwards it via send. Ingress for migrating or
+// unassigned slots is held (up to ReplayBufferSize per slot) and replayed to
+// the new owner with the bumped generation; until the replay is done, later
+// ingress for the slot queues behind it so the owner sees arrival order.
+func (r *Router) Dispatch(ctx context.Context, env *envelope.TransportEnvelope, send SendFunc) error {
+	lease, err := r.Resolve(ctx, env)
+	switch {
+	case err == nil:
+		return r.sendActive(ctx, lease, env, send)
+	case errors.Is(err, ErrSlotMigrating), errors.Is(err, ErrSlotUnassigned):
+		return r.hold(ctx, lease.Slot, env, send)
+	default:
+		return err
+	}
+}
+
+// Invalidate drops the cached route for slot, e.g. after a send to its owner failed.
+func (r *Router) Invalidate(slot uint32) {
+	r.mu.Lock()
+	delete(r.routes, slot)
+	r.mu.Unlock()
+}
+
+func (r *Router) lookup(ctx context.Context, slotID uint32) (Lease, bool, error) {
+	r.mu.Lock()
+	cached, hit := r.routes[slotID]
+	r.mu.Unlock()
+	if hit && time.Now().Before(cached.expiresAt) {
+		return cached.lease, cached.ok, nil
+	}
+	lease, ok, err := r.store.Get(ctx, slotID)
+	if err != nil {
+		return Lease{}, false, err
+	}
+	r.mu.Lock()
+	r.routes[slotID] = cachedRoute{lease: lease, ok: ok, expiresAt: time.Now().Add(r.routeTTL())}
+	r.mu.Unlock()
+	return lease, ok, nil
+}
+
+// hold queues env until the slot is acquired. The route is checked again
+// under r.mu: an EventAcquired applied since Resolve has already started the
+// replay, so env goes to the new owner behind it instead.
+func (r *Router) hold(ctx context.Context, slotID uint32, env *envelope.TransportEnvelope, send SendFunc) error {
+	r.mu.Lock()
+	if lease, ok := r.activeRoute(slotID); ok {
+		r.mu.Unlock()
+		envelope.SetSlot(env, slotID, lease.Generation)
+		return r.sendActive(ctx, lease, env, send)
+	}
+	defer r.mu.Unlock()
+	return r.queue(slotID, env, send)
+}
+
+// queue appends env to the held envelopes of slotID; the caller holds r.mu.
+func (r *Router) queue(slotID uint32, env *envelope.TransportEnvelope, send SendFunc) error {
+	if len(r.pending[slotID]) >= r.cfg.ReplayBufferSize {
+		return ErrReplayBufferFull
+	}
+	r.pending[slotID] = append(r.pending[slotID], heldEnvelope{env: env, send: send, heldAt: time.Now()})
+	return nil
+}
+
+// sendActive forwards env to lease unless held envelopes of the slot are
+// waiting or being replayed; env then queues behind them, and the replay is
+// started here when nobody runs it yet (the route was cached before the
+// EventAcquired arrived).
+func (r *Router) sendActive(ctx context.Context, lease Lease, env *envelope.TransportEnvelope, send SendFunc) error {
+	r.mu.Lock()
+	if !r.draining[lease.Slot] && len(r.pending[lease.Slot]) == 0 {
+		r.mu.Unlock()
+		return send(ctx, lease, env)
+	}
+	if err := r.queue(lease.Slot, env, send); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	start := !r.draining[lease.Slot]
+	r.draining[lease.Slot] = true
+	r.mu.Unlock()
+	if start {
+		// The queue holds other callers' ingress: do not let ctx cut it short.
+		r.drain(context.WithoutCancel(ctx), lease.Slot)
+	}
+	return nil
+}
+
+// activeRoute returns the cached lease of slotID when it can take traffic;
+// the caller holds r.mu.
+func (r *Router) activeRoute(slotID uint32) (Lease, bool) {
+	cached, hit := r.routes[slotID]
+	if hit && cached.ok && cached.lease.State == StateActive && time.Now().Before(cached.expiresAt) {
+		return cached.lease, true
+	}
+	return Lease{}, false
+}
+
+// drain replays the held envelopes of slotID in arrival order, including
+// those queued while it runs, then clears the draining mark. It stops early,
+// keeping the rest held, once the slot is no longer active.
+func (r *Router) drain(ctx context.Context, slotID uint32) {
+	for {
+		r.mu.Lock()
+		lease, ok := r.activeRoute(slotID)
+		batch := r.pending[slotID]
+		if !ok || len(batch) == 0 {
+			delete(r.draining, slotID)
+			r.mu.Unlock()
+			return
+		}
+		delete(r.pending, slotID)
+		r.mu.Unlock()
+		for _, h := range batch {
+			envelope.SetSlot(h.env, slotID, lease.Generation)
+			if err := h.send(ctx, lease, h.env); err != nil && r.OnDrop != nil {
+				r.OnDrop(h.env, err)
+			}
+		}
+	}
+}
+
+func (r *Router) apply(ctx context.Context, ev Event) {
+	replay := false
+	r.mu.Lock()
+	switch ev.Type {
+	case EventAcquired:
+		lease := Lease{Slot: ev.Slot, Owner: ev.Owner, Generation: ev.Generation, State: StateActive}
+		r.routes[ev.Slot] = cachedRoute{lease: lease, ok: true, expiresAt: time.Now().Add(r.routeTTL())}
+		replay = len(r.pending[ev.Slot]) > 0 && !r.draining[ev.Slot]
+		if replay {
+			r.draining[ev.Slot] = true
+		}
+	case EventMigrating:
+		lease := Lease{Slot: ev.Slot, Owner: ev.Owner, Generation: ev.Generation, State: StateMigrating, Target: ev.Target}
+		r.routes[ev.Slot] = cachedRoute{lease: lease, ok: true, expiresAt: time.Now().Add(r.routeTTL())}
+	case EventReleased:
+		delete(r.routes, ev.Slot)
+	}
+	r.mu.Unlock()
+
+	if r.OnRouteChange != nil {
+		r.OnRouteChange(ev)
+	}
+	if replay {
+		r.drain(ctx, ev.Slot)
+	}
+}
+
+func (r *Router) expireHeld() {
+	timeout := time.Duration(r.cfg.MigrationTimeoutSeconds) * time.Second
+	cutoff := time.Now().Add(-timeout)
+	var dropped []heldEnvelope
+	r.mu.Lock()
+	for slotID, held := range r.pending {
+		kept := held[:0]
+		for _, h := range held {
+			if h.heldAt.Before(cutoff) {
+				dropped = append(dropped, h)
+				continue
+			}
+			kept = append(kept, h)
+		}
+		if len(kept) == 0 {
+			delete(r.pending, slotID)
+		} else {
+			r.pending[slotID] = kept
+		}
+	}
+	r.mu.Unlock()
+	if r.OnDrop == nil {
+		return
+	}
+	for _, h := range dropped {
+		r.OnDrop(h.env, ErrSlotMigrating)
+	}
+}
+
+func (r *Router) routeTTL() time.Duration {
+	return time.Duration(r.cfg.RouteTTLSeconds) * time.Second
+}